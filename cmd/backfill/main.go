@@ -0,0 +1,66 @@
+// Command backfill writes booking extended properties onto calendar events
+// created before SlotBot stored them, so they no longer depend on the summary
+// format. It is safe to run more than once.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/lmittmann/tint"
+	"github.com/yossigruner/SlotBot/internal/calendar"
+	"github.com/yossigruner/SlotBot/internal/config"
+)
+
+func main() {
+	pastDays := flag.Int("past-days", 30, "how many days back to scan")
+	futureDays := flag.Int("future-days", 365, "how many days ahead to scan")
+	dryRun := flag.Bool("dry-run", false, "log the events that would be updated without changing them")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		slog.Warn("No .env file found, using environment variables")
+	}
+
+	logger := slog.New(tint.NewHandler(os.Stdout, &tint.Options{
+		Level:      slog.LevelInfo,
+		TimeFormat: time.TimeOnly,
+	}))
+	slog.SetDefault(logger)
+
+	if err := run(*pastDays, *futureDays, *dryRun); err != nil {
+		slog.Error("Backfill failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+func run(pastDays, futureDays int, dryRun bool) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	ctx := context.Background()
+	client, err := calendar.NewClient(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create calendar client: %w", err)
+	}
+
+	now := time.Now()
+	result, err := client.BackfillProperties(ctx, now.AddDate(0, 0, -pastDays), now.AddDate(0, 0, futureDays), dryRun)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("Backfill finished",
+		"updated", result.Updated,
+		"already_current", result.Current,
+		"malformed", result.Malformed,
+		"dry_run", dryRun)
+	return nil
+}
@@ -14,9 +14,13 @@ import (
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/joho/godotenv"
 	"github.com/lmittmann/tint"
+	"github.com/yossigruner/SlotBot/internal/api"
 	"github.com/yossigruner/SlotBot/internal/calendar"
 	"github.com/yossigruner/SlotBot/internal/config"
+	"github.com/yossigruner/SlotBot/internal/domain"
+	"github.com/yossigruner/SlotBot/internal/override"
 	"github.com/yossigruner/SlotBot/internal/slack"
+	"github.com/yossigruner/SlotBot/internal/waitlist"
 )
 
 func main() {
@@ -44,18 +48,54 @@ func run() error {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
+	var policy *calendar.Policy
+	if cfg.PolicyPath != "" {
+		policy, err = calendar.LoadPolicy(cfg.PolicyPath)
+		if err != nil {
+			return fmt.Errorf("failed to load booking policy: %w", err)
+		}
+		slog.Info("Loaded booking policy", "path", cfg.PolicyPath, "envs", policy.EnvNames())
+	}
+
 	ctx := context.Background()
-	calClient, err := calendar.NewClient(ctx, cfg)
+	var blackouts *calendar.BlackoutFeed
+	if cfg.BlackoutCalendarID != "" {
+		client, err := calendar.NewClient(ctx, cfg)
+		if err != nil {
+			return fmt.Errorf("failed to create client for blackout calendar: %w", err)
+		}
+		blackouts = calendar.NewBlackoutFeed(func(ctx context.Context, start, end time.Time) ([]domain.Blackout, error) {
+			return client.ListBlackouts(ctx, cfg.BlackoutCalendarID, start, end)
+		})
+		if err := blackouts.Refresh(ctx); err != nil {
+			slog.Warn("Failed to read blackout calendar, will retry", "error", err)
+		}
+	}
+	rules := calendar.NewRules(policy, cfg.Schedules, blackouts)
+
+	store, err := calendar.NewStore(ctx, cfg)
 	if err != nil {
-		slog.Warn("Failed to create calendar client - calendar features will not work", "error", err)
-		slog.Warn("To enable calendar features, follow OAUTH_SETUP.md to configure credentials")
-		// Continue without calendar client for now
-		calClient = nil
-	} else {
-		slog.Info("Calendar client initialized successfully")
+		// Falling back to memory would take bookings nobody else sees and
+		// lose them on restart; BOOKING_STORE=memory asks for that explicitly
+		return fmt.Errorf("failed to create %s booking store: %w", cfg.BookingStore, err)
 	}
+	if cfg.BookingStore == config.StoreMemory {
+		slog.Warn("Using in-memory bookings: they are lost on restart")
+	}
+	slog.Info("Booking store initialized successfully", "store", cfg.BookingStore)
+
+	queue, err := waitlist.Open(cfg.WaitlistPath)
+	if err != nil {
+		return fmt.Errorf("failed to load waitlist: %w", err)
+	}
+	slackAPI := slack.NewAPIClient(cfg.SlackBotToken)
+	timezones := slack.NewTimezones(slackAPI, cfg.DefaultTimezone)
+	teams := slack.NewTeams(slackAPI)
+	dispatcher := waitlist.NewDispatcher(queue, store, rules, slackAPI, timezones, teams)
+
+	approvals := slack.NewApprovals(slackAPI, override.OpenAudit(cfg.AuditLogPath))
 
-	slackHandler := slack.NewHandler(calClient, cfg.GoogleCalendarID)
+	slackHandler := slack.NewHandler(store, rules, dispatcher, timezones, teams, approvals, cfg)
 
 	r := chi.NewRouter()
 	r.Use(middleware.RequestID)
@@ -68,8 +108,19 @@ func run() error {
 		r.Use(slack.VerifySignature(cfg.SlackSigningSecret))
 		// Unified command
 		r.Post("/slot", slackHandler.HandleUnified)
+		// Buttons on interactive messages, like urgent booking approvals
+		r.Post("/interactive", slackHandler.HandleInteraction)
 	})
 
+	// Read-only HTTP API, only served when a token is configured
+	if cfg.APIToken != "" {
+		apiHandler := api.NewHandler(store, rules)
+		r.Route("/api", func(r chi.Router) {
+			r.Use(api.RequireToken(cfg.APIToken))
+			r.Get("/grid/{env}", apiHandler.HandleGrid)
+		})
+	}
+
 	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("OK"))
 	})
@@ -82,6 +133,12 @@ func run() error {
 	// Server run context
 	serverCtx, serverStopCtx := context.WithCancel(context.Background())
 
+	// Hand environments to the waitlist when bookings run out
+	go dispatcher.Run(serverCtx, time.Minute)
+	if blackouts != nil {
+		go blackouts.Run(serverCtx, 5*time.Minute)
+	}
+
 	// Listen for syscall signals for process to interrupt/quit
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
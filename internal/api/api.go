@@ -0,0 +1,70 @@
+// Package api serves read-only booking views over HTTP for dashboards and
+// scripts, next to the Slack endpoints.
+package api
+
+import (
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/yossigruner/SlotBot/internal/calendar"
+	"github.com/yossigruner/SlotBot/internal/grid"
+	"github.com/yossigruner/SlotBot/internal/timeexpr"
+)
+
+type Handler struct {
+	store calendar.BookingStore
+	rules *calendar.Rules
+}
+
+func NewHandler(store calendar.BookingStore, rules *calendar.Rules) *Handler {
+	return &Handler{store: store, rules: rules}
+}
+
+// RequireToken rejects requests without "Authorization: Bearer <token>"
+func RequireToken(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				http.Error(w, "Invalid token", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// HandleGrid serves GET /api/grid/{env}?date=<day> as plain text, the same
+// timeline /slot grid shows. date takes anything /slot list does for a
+// single day and defaults to today. env may be an alias.
+func (h *Handler) HandleGrid(w http.ResponseWriter, r *http.Request) {
+	env, err := h.rules.ResolveEnv(chi.URLParam(r, "env"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	days, err := timeexpr.ParseDays(r.URL.Query().Get("date"), time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !days.End.Equal(days.Start.AddDate(0, 0, 1)) {
+		http.Error(w, "grid shows a single day", http.StatusBadRequest)
+		return
+	}
+
+	events, err := h.store.ListEvents(r.Context(), days.Start, days.End)
+	if err != nil {
+		slog.Error("Failed to list events for grid", "error", err)
+		http.Error(w, "failed to check calendar", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte(grid.New(env, days.Start, events).String()))
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/yossigruner/SlotBot/internal/calendar"
+	"github.com/yossigruner/SlotBot/internal/domain"
+)
+
+func TestHandleGrid(t *testing.T) {
+	store := calendar.NewMemoryStore()
+	tomorrow := time.Now().AddDate(0, 0, 1)
+	start := time.Date(tomorrow.Year(), tomorrow.Month(), tomorrow.Day(), 9, 0, 0, 0, tomorrow.Location())
+	if _, err := store.CreateEvent(context.Background(), domain.Booking{
+		Env: "staging", Service: "api", JiraTicket: "PROJ-1", StartTime: start, Duration: time.Hour,
+	}); err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+
+	r := chi.NewRouter()
+	r.Use(RequireToken("secret"))
+	r.Get("/api/grid/{env}", NewHandler(store, calendar.NewRules(nil, nil, nil)).HandleGrid)
+
+	tests := []struct {
+		name     string
+		path     string
+		token    string
+		wantCode int
+		wantBody string
+	}{
+		{name: "Grid", path: "/api/grid/staging?date=tomorrow", token: "secret", wantCode: http.StatusOK, wantBody: "api  ....####"},
+		{name: "Missing token", path: "/api/grid/staging", wantCode: http.StatusUnauthorized},
+		{name: "Wrong token", path: "/api/grid/staging", token: "guess", wantCode: http.StatusUnauthorized},
+		{name: "Bad date", path: "/api/grid/staging?date=someday", token: "secret", wantCode: http.StatusBadRequest},
+		{name: "Range", path: "/api/grid/staging?date=week", token: "secret", wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("GET %s = %d, want %d", tt.path, rec.Code, tt.wantCode)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("GET %s body = %q, want it to contain %q", tt.path, rec.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
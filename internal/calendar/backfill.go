@@ -0,0 +1,73 @@
+package calendar
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/yossigruner/SlotBot/internal/domain"
+	"google.golang.org/api/calendar/v3"
+)
+
+// BackfillResult summarises a BackfillProperties run.
+type BackfillResult struct {
+	Updated   int // Events that got extended properties
+	Current   int // Events that already had them
+	Malformed int // Events whose summary could not be parsed
+}
+
+// BackfillProperties writes booking extended properties onto events in
+// [start, end) that were created before the bot stored them, using the
+// summary as the source. The event ID becomes the booking ID. With dryRun
+// set, nothing is written.
+func (c *Client) BackfillProperties(ctx context.Context, start, end time.Time, dryRun bool) (BackfillResult, error) {
+	var result BackfillResult
+
+	// Recurring events are patched once on the parent, so don't expand them
+	call := c.srv.Events.List(c.calendarID).
+		ShowDeleted(false).
+		SingleEvents(false).
+		TimeMin(start.Format(time.RFC3339)).
+		TimeMax(end.Format(time.RFC3339))
+
+	err := call.Pages(ctx, func(page *calendar.Events) error {
+		for _, item := range page.Items {
+			if hasBookingProperties(item) {
+				result.Current++
+				continue
+			}
+
+			meta := parseSummary(item.Summary)
+			if meta.Env == "" || meta.Service == "" {
+				slog.Warn("Skipping event with unparseable summary", "event_id", item.Id, "summary", item.Summary)
+				result.Malformed++
+				continue
+			}
+
+			booking := domain.Booking{
+				Env:        meta.Env,
+				Service:    meta.Service,
+				JiraTicket: meta.JiraTicket,
+				User:       meta.User,
+			}
+			patch := &calendar.Event{ExtendedProperties: bookingProperties(item.Id, booking)}
+
+			if dryRun {
+				slog.Info("Would backfill event", "event_id", item.Id, "summary", item.Summary)
+			} else {
+				if _, err := c.srv.Events.Patch(c.calendarID, item.Id, patch).Context(ctx).Do(); err != nil {
+					return fmt.Errorf("unable to patch event %s: %w", item.Id, err)
+				}
+				slog.Info("Backfilled event", "event_id", item.Id, "summary", item.Summary)
+			}
+			result.Updated++
+		}
+		return nil
+	})
+	if err != nil {
+		return result, fmt.Errorf("unable to backfill events: %w", err)
+	}
+
+	return result, nil
+}
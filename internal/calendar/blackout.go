@@ -0,0 +1,185 @@
+package calendar
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yossigruner/SlotBot/internal/domain"
+)
+
+// blackoutRule is a blackout from the policy file: either a one-off window
+// from From to To, or a weekly one from Start to End on Days. Times are
+// wall-clock times in Location, or in the env's working-hours timezone.
+type blackoutRule struct {
+	Envs     []string // Lower-cased; empty means every env
+	Reason   string
+	Location *time.Location
+
+	From, To time.Time // One-off, as wall-clock times
+
+	Weekly     bool
+	Days       map[time.Weekday]bool // Empty means every day
+	Start, End time.Duration         // Time of day; an End before Start runs past midnight
+}
+
+// BlackoutEvents returns the blackout windows in env overlapping [start, end),
+// as whole-environment locks whose Title gives the reason
+func (r *Rules) BlackoutEvents(env string, start, end time.Time) []domain.Event {
+	name := strings.ToLower(env)
+	windows := r.blackoutWindows(name, start, end)
+
+	events := make([]domain.Event, len(windows))
+	for i, w := range windows {
+		events[i] = domain.Event{
+			Title:     "⛔ Blackout: " + w.Reason,
+			StartTime: w.Start,
+			EndTime:   w.End,
+			Env:       name,
+			Service:   domain.AllServices,
+			Blackout:  true,
+		}
+	}
+	return events
+}
+
+// blackoutWindows returns the policy's and the feed's blackouts in env
+// overlapping [start, end), in order
+func (r *Rules) blackoutWindows(env string, start, end time.Time) []domain.Blackout {
+	windows := r.policy.blackouts(env, start, end, location(r.ScheduleFor(env)))
+	if r.blackouts != nil {
+		windows = append(windows, r.blackouts.in(env, start, end)...)
+	}
+	slices.SortFunc(windows, func(a, b domain.Blackout) int { return a.Start.Compare(b.Start) })
+	return windows
+}
+
+// checkBlackouts reports the first blackout any occurrence of b falls in
+func (r *Rules) checkBlackouts(b domain.Booking) error {
+	loc := location(r.ScheduleFor(b.Env))
+	for _, start := range Occurrences(b) {
+		if windows := r.blackoutWindows(strings.ToLower(b.Env), start, start.Add(b.Duration)); len(windows) > 0 {
+			w := windows[0]
+			return fmt.Errorf("%s is blacked out %s - %s: %s", b.Env,
+				w.Start.In(loc).Format("Mon 02 Jan 15:04"), w.End.In(loc).Format("Mon 02 Jan 15:04"), w.Reason)
+		}
+	}
+	return nil
+}
+
+// blackouts expands the policy's blackout rules for env over [start, end).
+// Rules without a timezone are read in envLoc, the env's.
+func (p *Policy) blackouts(env string, start, end time.Time, envLoc *time.Location) []domain.Blackout {
+	var windows []domain.Blackout
+	for _, r := range p.blackoutRules {
+		if len(r.Envs) > 0 && !slices.Contains(r.Envs, env) {
+			continue
+		}
+		loc := r.Location
+		if loc == nil {
+			loc = envLoc
+		}
+
+		if !r.Weekly {
+			from, to := wallClock(r.From, loc), wallClock(r.To, loc)
+			if from.Before(end) && to.After(start) {
+				windows = append(windows, domain.Blackout{Envs: r.Envs, Start: from, End: to, Reason: r.Reason})
+			}
+			continue
+		}
+
+		// A window that runs past midnight may have started the day before
+		first, _ := dayOf(start, loc)
+		for day := first.AddDate(0, 0, -1); day.Before(end); day = day.AddDate(0, 0, 1) {
+			if len(r.Days) > 0 && !r.Days[day.Weekday()] {
+				continue
+			}
+			from := atTimeOfDay(day, r.Start)
+			to := atTimeOfDay(day, r.End)
+			if r.End <= r.Start {
+				to = atTimeOfDay(day.AddDate(0, 0, 1), r.End)
+			}
+			if from.Before(end) && to.After(start) {
+				windows = append(windows, domain.Blackout{Envs: r.Envs, Start: from, End: to, Reason: r.Reason})
+			}
+		}
+	}
+	return windows
+}
+
+// wallClock reads t's date and time of day in loc
+func wallClock(t time.Time, loc *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, loc)
+}
+
+// atTimeOfDay returns the wall-clock time d after midnight on day, so DST
+// changes don't shift it
+func atTimeOfDay(day time.Time, d time.Duration) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), int(d.Hours()), int(d.Minutes())%60, 0, 0, day.Location())
+}
+
+// BlackoutFeed keeps the blackouts from a calendar in memory, so checking a
+// booking doesn't wait on the calendar. Run keeps it up to date.
+type BlackoutFeed struct {
+	list func(ctx context.Context, start, end time.Time) ([]domain.Blackout, error)
+
+	mu      sync.RWMutex
+	windows []domain.Blackout
+}
+
+// NewBlackoutFeed creates a feed that reads blackouts with list
+func NewBlackoutFeed(list func(ctx context.Context, start, end time.Time) ([]domain.Blackout, error)) *BlackoutFeed {
+	return &BlackoutFeed{list: list}
+}
+
+// Refresh reads the blackouts from a day ago up to a year ahead. On error
+// the feed keeps the blackouts it had.
+func (f *BlackoutFeed) Refresh(ctx context.Context) error {
+	now := time.Now()
+	windows, err := f.list(ctx, now.Add(-24*time.Hour), now.Add(maxSearch))
+	if err != nil {
+		return fmt.Errorf("refresh blackouts: %w", err)
+	}
+
+	f.mu.Lock()
+	f.windows = windows
+	f.mu.Unlock()
+	return nil
+}
+
+// Run refreshes the feed each interval until ctx is cancelled
+func (f *BlackoutFeed) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := f.Refresh(ctx); err != nil {
+				slog.Warn("Failed to refresh blackout calendar, keeping the last known blackouts", "error", err)
+			}
+		}
+	}
+}
+
+func (f *BlackoutFeed) in(env string, start, end time.Time) []domain.Blackout {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	var windows []domain.Blackout
+	for _, w := range f.windows {
+		if len(w.Envs) > 0 && !slices.ContainsFunc(w.Envs, func(e string) bool { return strings.EqualFold(e, env) }) {
+			continue
+		}
+		if w.Start.Before(end) && w.End.After(start) {
+			windows = append(windows, w)
+		}
+	}
+	return windows
+}
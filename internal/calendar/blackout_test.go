@@ -0,0 +1,117 @@
+package calendar
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yossigruner/SlotBot/internal/domain"
+)
+
+func TestBlackoutEvents(t *testing.T) {
+	p, err := ParsePolicy([]byte(`
+envs:
+  qa: {}
+  staging: {}
+blackouts:
+  - envs: [qa]
+    days: tue
+    time: 22:00-02:00
+    timezone: UTC
+    reason: DB maintenance
+  - from: 2030-01-10
+    to: 2030-01-10
+    timezone: UTC
+    reason: Release freeze
+`))
+	if err != nil {
+		t.Fatalf("ParsePolicy() error = %v", err)
+	}
+	rules := NewRules(p, nil, nil)
+
+	// 2030-01-01 is a Tuesday
+	tests := []struct {
+		name       string
+		env        string
+		start, end time.Time
+		want       []string
+	}{
+		{"Weekly window", "qa", time.Date(2030, 1, 1, 21, 0, 0, 0, time.UTC), time.Date(2030, 1, 1, 23, 0, 0, 0, time.UTC), []string{"2030-01-01 22:00 - 2030-01-02 02:00"}},
+		{"Past midnight", "QA", time.Date(2030, 1, 2, 1, 0, 0, 0, time.UTC), time.Date(2030, 1, 2, 1, 30, 0, 0, time.UTC), []string{"2030-01-01 22:00 - 2030-01-02 02:00"}},
+		{"Other day", "qa", time.Date(2030, 1, 2, 21, 0, 0, 0, time.UTC), time.Date(2030, 1, 2, 23, 0, 0, 0, time.UTC), nil},
+		{"Other env", "staging", time.Date(2030, 1, 1, 21, 0, 0, 0, time.UTC), time.Date(2030, 1, 1, 23, 0, 0, 0, time.UTC), nil},
+		{"Whole day in every env", "staging", time.Date(2030, 1, 10, 9, 0, 0, 0, time.UTC), time.Date(2030, 1, 10, 10, 0, 0, 0, time.UTC), []string{"2030-01-10 00:00 - 2030-01-11 00:00"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, e := range rules.BlackoutEvents(tt.env, tt.start, tt.end) {
+				if !e.Blackout || e.Service != domain.AllServices {
+					t.Errorf("event = %+v, want a blackout locking every service", e)
+				}
+				got = append(got, e.StartTime.UTC().Format("2006-01-02 15:04")+" - "+e.EndTime.UTC().Format("2006-01-02 15:04"))
+			}
+			if strings.Join(got, ";") != strings.Join(tt.want, ";") {
+				t.Errorf("BlackoutEvents() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBlackoutsBlockBookings(t *testing.T) {
+	tomorrow := time.Now().UTC().AddDate(0, 0, 1)
+	day := tomorrow.Format(time.DateOnly)
+	p, err := ParsePolicy([]byte(fmt.Sprintf(`
+envs:
+  qa: {}
+blackouts:
+  - envs: [qa]
+    from: %s 10:00
+    to: %s 12:00
+    timezone: UTC
+    reason: Load test
+`, day, day)))
+	if err != nil {
+		t.Fatalf("ParsePolicy() error = %v", err)
+	}
+	rules := NewRules(p, nil, nil)
+
+	start := time.Date(tomorrow.Year(), tomorrow.Month(), tomorrow.Day(), 11, 0, 0, 0, time.UTC)
+	b := domain.Booking{Env: "qa", Service: "api", StartTime: start, Duration: time.Hour}
+
+	if err := rules.ValidateBooking(b); err == nil || !strings.Contains(err.Error(), "Load test") {
+		t.Errorf("ValidateBooking() error = %v, want the blackout's reason", err)
+	}
+
+	conflict := rules.CheckConflict(b, nil)
+	if conflict == nil || !conflict.Blackout || !strings.Contains(conflict.Title, "Load test") {
+		t.Errorf("CheckConflict() = %+v, want the blackout", conflict)
+	}
+
+	now := start.Add(-30 * time.Minute)
+	got, err := rules.findNextSlot("qa", []string{"api"}, time.Hour, nil, now)
+	if want := start.Add(time.Hour); err != nil || !got.Equal(want) {
+		t.Errorf("findNextSlot() = %v, %v, want %v after the blackout", got, err, want)
+	}
+}
+
+func TestBlackoutFeed(t *testing.T) {
+	start := time.Now().Add(time.Hour).Truncate(time.Hour)
+	feed := NewBlackoutFeed(func(ctx context.Context, from, to time.Time) ([]domain.Blackout, error) {
+		return []domain.Blackout{{Envs: []string{"Staging"}, Start: start, End: start.Add(time.Hour), Reason: "Pen test"}}, nil
+	})
+	if err := feed.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	rules := NewRules(nil, nil, feed)
+
+	if got := rules.BlackoutEvents("staging", start, start.Add(time.Minute)); len(got) != 1 || got[0].Title != "⛔ Blackout: Pen test" {
+		t.Errorf("BlackoutEvents(staging) = %+v, want the feed's blackout", got)
+	}
+	if got := rules.BlackoutEvents("qa", start, start.Add(time.Minute)); len(got) != 0 {
+		t.Errorf("BlackoutEvents(qa) = %+v, want none", got)
+	}
+}
@@ -1,101 +1,196 @@
 package calendar
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"strings"
 	"time"
 
 	"github.com/yossigruner/SlotBot/internal/domain"
 )
 
-func CheckConflict(newBooking domain.Booking, existingEvents []domain.Event) *domain.Event {
+func (r *Rules) CheckConflict(newBooking domain.Booking, existingEvents []domain.Event) *domain.Event {
+	if conflicts := r.Conflicts(newBooking, existingEvents); len(conflicts) > 0 {
+		return &conflicts[0]
+	}
+	return nil
+}
+
+// Conflicts returns every event newBooking clashes with: the blackouts it
+// falls in first (see Rules.BlackoutEvents), then existingEvents in order
+func (r *Rules) Conflicts(newBooking domain.Booking, existingEvents []domain.Event) []domain.Event {
 	newStart := newBooking.StartTime
 	newEnd := newBooking.StartTime.Add(newBooking.Duration)
 
+	conflicts := r.BlackoutEvents(newBooking.Env, newStart, newEnd)
 	for _, event := range existingEvents {
+		// An existing booking being changed can't conflict with itself
+		if newBooking.ID != "" && event.ID == newBooking.ID {
+			continue
+		}
+
 		// Check if env and service match
 		if !strings.EqualFold(event.Env, newBooking.Env) ||
-			!strings.EqualFold(event.Service, newBooking.Service) {
+			!servicesOverlap(event.Service, newBooking.Service) {
 			continue
 		}
 
 		// Check for time overlap
 		// Overlap exists if (StartA < EndB) and (EndA > StartB)
 		if event.StartTime.Before(newEnd) && event.EndTime.After(newStart) {
-			return &event
+			conflicts = append(conflicts, event)
 		}
 	}
 
-	return nil
+	return conflicts
 }
 
-func ValidateBooking(b domain.Booking) error {
-	validEnvs := map[string]bool{
-		"staging": true,
-		"qa":      true,
-		"demo":    true,
+// ValidateBooking checks b against the booking policy, the env's working
+// hours and its blackouts. Policy violations are *PolicyError.
+func (r *Rules) ValidateBooking(b domain.Booking) error {
+	now := time.Now()
+	if err := r.policy.check(b, now); err != nil {
+		return err
 	}
 
-	if !validEnvs[strings.ToLower(b.Env)] {
-		return fmt.Errorf("invalid environment: %s. Must be staging, qa, or demo", b.Env)
+	if b.Recurrence != nil {
+		if err := validateRecurrence(b); err != nil {
+			return err
+		}
 	}
 
-	if b.Duration > 2*time.Hour {
-		return fmt.Errorf("maximum booking duration is 2 hours")
+	if err := r.checkSchedule(b, now); err != nil {
+		return err
 	}
+	return r.checkBlackouts(b)
+}
 
-	if b.Duration < 5*time.Minute {
-		return fmt.Errorf("minimum booking duration is 5 minutes")
+// CreateBookings creates all bookings or none: if one fails, the ones
+// already created are deleted again.
+func CreateBookings(ctx context.Context, store BookingStore, bookings []domain.Booking) ([]domain.Event, error) {
+	var created []domain.Event
+	for _, b := range bookings {
+		event, err := store.CreateEvent(ctx, b)
+		if err != nil {
+			for _, e := range created {
+				if delErr := store.DeleteEvent(ctx, e.ID); delErr != nil {
+					slog.Error("Failed to roll back booking", "error", delErr, "event_id", e.ID, "env", e.Env, "service", e.Service)
+				}
+			}
+			return nil, fmt.Errorf("booking %s/%s failed, rolled back %d bookings: %w", b.Env, b.Service, len(created), err)
+		}
+		created = append(created, *event)
 	}
+	return created, nil
+}
 
-	return nil
+// FindNextSlot finds the earliest start from now when service in env is
+// free for the whole duration, within the env's working hours. It returns
+// ErrNoSlot if nothing is free within the booking horizon.
+func (r *Rules) FindNextSlot(env, service string, duration time.Duration, existingEvents []domain.Event) (time.Time, error) {
+	return r.FindNextSlotForServices(env, []string{service}, duration, existingEvents)
+}
+
+// FindNextSlotForServices finds the earliest start from now when every one of
+// the services in env is free for the whole duration, like FindNextSlot.
+func (r *Rules) FindNextSlotForServices(env string, services []string, duration time.Duration, existingEvents []domain.Event) (time.Time, error) {
+	return r.findNextSlot(env, services, duration, existingEvents, time.Now())
+}
+
+func (r *Rules) findNextSlot(env string, services []string, duration time.Duration, existingEvents []domain.Event, now time.Time) (time.Time, error) {
+	slots, err := r.freeSlots(env, services, duration, existingEvents, now, now, 1)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return slots[0].Start, nil
+}
+
+// Slot is a free interval
+type Slot struct {
+	Start time.Time
+	End   time.Time
+}
+
+// FreeSlots lists up to n free intervals in env from the given time on, in
+// order, each long enough for duration and within working hours. Every
+// slot is the whole gap: it ends when the next booking starts or the env
+// closes, or at the booking horizon. It returns ErrNoSlot if there is none.
+func (r *Rules) FreeSlots(env string, services []string, duration time.Duration, existingEvents []domain.Event, from time.Time, n int) ([]Slot, error) {
+	return r.freeSlots(env, services, duration, existingEvents, from, time.Now(), n)
 }
 
-func FindNextSlot(env, service string, duration time.Duration, existingEvents []domain.Event) time.Time {
-	// We assume existingEvents are sorted by StartTime (Calendar API does this)
-	// Filter events for this env/service
+func (r *Rules) freeSlots(env string, services []string, duration time.Duration, existingEvents []domain.Event, from, now time.Time, n int) ([]Slot, error) {
+	// Filter events for this env and any of the services
 	var relevantEvents []domain.Event
 	for _, e := range existingEvents {
-		if strings.EqualFold(e.Env, env) &&
-			strings.EqualFold(e.Service, service) {
-			relevantEvents = append(relevantEvents, e)
+		if !strings.EqualFold(e.Env, env) {
+			continue
+		}
+		for _, service := range services {
+			if servicesOverlap(e.Service, service) {
+				relevantEvents = append(relevantEvents, e)
+				break
+			}
 		}
 	}
 
-	// Start looking from now (rounded up to next 15 mins for cleanliness, or just now)
-	// Let's say "now"
-	searchStart := time.Now()
-
-	// Check gap before first event
-	if len(relevantEvents) == 0 {
-		return searchStart
+	schedule := r.ScheduleFor(env)
+	limit := now.Add(maxSearch)
+	if horizon := r.Horizon(env); horizon > 0 {
+		limit = now.Add(horizon)
 	}
-
-	// If first event starts after (now + duration), then we can book now
-	if relevantEvents[0].StartTime.Sub(searchStart) >= duration {
-		return searchStart
+	if from.Before(limit) {
+		relevantEvents = append(relevantEvents, r.BlackoutEvents(env, from, limit)...)
 	}
 
-	// Check gaps between events
-	for i := 0; i < len(relevantEvents)-1; i++ {
-		currentEnd := relevantEvents[i].EndTime
-		nextStart := relevantEvents[i+1].StartTime
+	// Every pass moves freeFrom forward: to the next opening, past an
+	// opening too short for the duration, or past the bookings in the way
+	var slots []Slot
+	freeFrom := from
+	for len(slots) < n {
+		open, ok := nextOpen(schedule, freeFrom, limit)
+		if !ok {
+			break
+		}
+		freeFrom = open
+		end := freeFrom.Add(duration)
+
+		if !fits(schedule, freeFrom, end) {
+			freeFrom = closesAt(schedule, freeFrom, limit)
+			continue
+		}
 
-		// If current event ends in the past, treat it as "now" (or ignore)
-		if currentEnd.Before(searchStart) {
-			currentEnd = searchStart
+		var busyUntil time.Time
+		for _, e := range relevantEvents {
+			if e.StartTime.Before(end) && e.EndTime.After(freeFrom) && e.EndTime.After(busyUntil) {
+				busyUntil = e.EndTime
+			}
+		}
+		if !busyUntil.IsZero() {
+			freeFrom = busyUntil
+			continue
 		}
 
-		// If gap is big enough
-		if nextStart.Sub(currentEnd) >= duration {
-			return currentEnd
+		// The gap runs until the next booking or closing, whichever is first
+		gapEnd := closesAt(schedule, freeFrom, limit)
+		for _, e := range relevantEvents {
+			if e.StartTime.After(freeFrom) && e.StartTime.Before(gapEnd) {
+				gapEnd = e.StartTime
+			}
 		}
+		slots = append(slots, Slot{Start: freeFrom, End: gapEnd})
+		freeFrom = gapEnd
 	}
 
-	// Check after last event
-	lastEventEnd := relevantEvents[len(relevantEvents)-1].EndTime
-	if lastEventEnd.Before(searchStart) {
-		return searchStart
+	if len(slots) == 0 {
+		return nil, ErrNoSlot
 	}
-	return lastEventEnd
+	return slots, nil
+}
+
+// servicesOverlap reports whether bookings of the two services compete for
+// the same resource: the same service, or a whole-environment lock.
+func servicesOverlap(a, b string) bool {
+	return a == domain.AllServices || b == domain.AllServices || strings.EqualFold(a, b)
 }
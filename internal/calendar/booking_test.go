@@ -1,6 +1,8 @@
 package calendar
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -8,6 +10,7 @@ import (
 )
 
 func TestValidateBooking(t *testing.T) {
+	rules := NewRules(nil, nil, nil)
 	tests := []struct {
 		name    string
 		booking domain.Booking
@@ -49,7 +52,7 @@ func TestValidateBooking(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if err := ValidateBooking(tt.booking); (err != nil) != tt.wantErr {
+			if err := rules.ValidateBooking(tt.booking); (err != nil) != tt.wantErr {
 				t.Errorf("ValidateBooking() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -57,10 +60,12 @@ func TestValidateBooking(t *testing.T) {
 }
 
 func TestCheckConflict(t *testing.T) {
+	rules := NewRules(nil, nil, nil)
 	now := time.Now()
 
 	existingEvents := []domain.Event{
 		{
+			ID:        "abc123",
 			Env:       "staging",
 			Service:   "auth",
 			StartTime: now.Add(1 * time.Hour),
@@ -103,6 +108,37 @@ func TestCheckConflict(t *testing.T) {
 			},
 			wantConf: false,
 		},
+		{
+			name: "No conflict - same booking being changed",
+			booking: domain.Booking{
+				ID:        "abc123",
+				Env:       "staging",
+				Service:   "auth",
+				StartTime: now.Add(1 * time.Hour),
+				Duration:  2 * time.Hour,
+			},
+			wantConf: false,
+		},
+		{
+			name: "Conflict - whole environment lock",
+			booking: domain.Booking{
+				Env:       "staging",
+				Service:   domain.AllServices,
+				StartTime: now.Add(90 * time.Minute),
+				Duration:  time.Hour,
+			},
+			wantConf: true,
+		},
+		{
+			name: "No conflict - whole environment lock elsewhere",
+			booking: domain.Booking{
+				Env:       "qa",
+				Service:   domain.AllServices,
+				StartTime: now.Add(1 * time.Hour),
+				Duration:  time.Hour,
+			},
+			wantConf: false,
+		},
 		{
 			name: "Conflict - exact match",
 			booking: domain.Booking{
@@ -137,7 +173,7 @@ func TestCheckConflict(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := CheckConflict(tt.booking, existingEvents)
+			got := rules.CheckConflict(tt.booking, existingEvents)
 			if (got != nil) != tt.wantConf {
 				t.Errorf("CheckConflict() conflict = %v, wantConf %v", got, tt.wantConf)
 			}
@@ -146,6 +182,7 @@ func TestCheckConflict(t *testing.T) {
 }
 
 func TestFindNextSlot(t *testing.T) {
+	rules := NewRules(nil, nil, nil)
 	now := time.Now()
 	// Round now to minute for stable comparison if needed, but logic uses exact time
 
@@ -188,7 +225,10 @@ func TestFindNextSlot(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := FindNextSlot("staging", "auth", tt.duration, existingEvents)
+			got, err := rules.FindNextSlot("staging", "auth", tt.duration, existingEvents)
+			if err != nil {
+				t.Fatalf("FindNextSlot() error = %v", err)
+			}
 
 			// Allow small delta for "now" comparisons
 			diff := got.Sub(tt.want)
@@ -201,3 +241,142 @@ func TestFindNextSlot(t *testing.T) {
 		})
 	}
 }
+
+func TestFindNextSlotForServices(t *testing.T) {
+	rules := NewRules(nil, nil, nil)
+	now := time.Now()
+
+	// api is busy soon, web overlaps it and runs longer
+	existingEvents := []domain.Event{
+		{
+			Env:       "staging",
+			Service:   "api",
+			StartTime: now.Add(30 * time.Minute),
+			EndTime:   now.Add(2 * time.Hour),
+		},
+		{
+			Env:       "staging",
+			Service:   "web",
+			StartTime: now.Add(1 * time.Hour),
+			EndTime:   now.Add(3 * time.Hour),
+		},
+		{
+			Env:       "staging",
+			Service:   "worker",
+			StartTime: now.Add(4 * time.Hour),
+			EndTime:   now.Add(5 * time.Hour),
+		},
+	}
+
+	tests := []struct {
+		name     string
+		services []string
+		duration time.Duration
+		want     time.Time
+	}{
+		{
+			name:     "Single service gap",
+			services: []string{"web"},
+			duration: 30 * time.Minute,
+			want:     now,
+		},
+		{
+			name:     "All free only after the overlapping events",
+			services: []string{"api", "web"},
+			duration: time.Hour,
+			want:     now.Add(3 * time.Hour),
+		},
+		{
+			name:     "Whole environment lock waits for every service",
+			services: []string{domain.AllServices},
+			duration: time.Hour,
+			want:     now.Add(3 * time.Hour),
+		},
+		{
+			name:     "Gap between services too short",
+			services: []string{"api", "web", "worker"},
+			duration: 90 * time.Minute,
+			want:     now.Add(5 * time.Hour),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rules.FindNextSlotForServices("staging", tt.services, tt.duration, existingEvents)
+			if err != nil {
+				t.Fatalf("FindNextSlotForServices() error = %v", err)
+			}
+
+			diff := got.Sub(tt.want)
+			if diff < 0 {
+				diff = -diff
+			}
+			if diff > time.Second {
+				t.Errorf("FindNextSlotForServices() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// failingStore fails every CreateEvent after the first failAfter successes.
+type failingStore struct {
+	*MemoryStore
+	failAfter int
+}
+
+func (f *failingStore) CreateEvent(ctx context.Context, booking domain.Booking) (*domain.Event, error) {
+	if f.failAfter == 0 {
+		return nil, errors.New("calendar unavailable")
+	}
+	f.failAfter--
+	return f.MemoryStore.CreateEvent(ctx, booking)
+}
+
+func TestCreateBookingsRollback(t *testing.T) {
+	ctx := context.Background()
+	store := &failingStore{MemoryStore: NewMemoryStore(), failAfter: 2}
+	start := time.Date(2030, 1, 7, 10, 0, 0, 0, time.UTC)
+
+	var bookings []domain.Booking
+	for _, svc := range []string{"api", "web", "worker"} {
+		bookings = append(bookings, domain.Booking{Env: "staging", Service: svc, StartTime: start, Duration: time.Hour})
+	}
+
+	if _, err := CreateBookings(ctx, store, bookings); err == nil {
+		t.Fatal("CreateBookings() error = nil, want failure on the third booking")
+	}
+
+	events, _ := store.ListEvents(ctx, start, start.Add(time.Hour))
+	if len(events) != 0 {
+		t.Errorf("ListEvents() after rollback returned %d events, want 0", len(events))
+	}
+}
+
+func TestCheckConflictWithLock(t *testing.T) {
+	rules := NewRules(nil, nil, nil)
+	now := time.Now()
+
+	// qa is locked for a migration
+	existingEvents := []domain.Event{
+		{
+			Env:       "qa",
+			Service:   domain.AllServices,
+			StartTime: now.Add(1 * time.Hour),
+			EndTime:   now.Add(2 * time.Hour),
+		},
+	}
+
+	booking := domain.Booking{
+		Env:       "qa",
+		Service:   "api",
+		StartTime: now.Add(90 * time.Minute),
+		Duration:  time.Hour,
+	}
+	if got := rules.CheckConflict(booking, existingEvents); got == nil {
+		t.Errorf("CheckConflict() = nil, want conflict with the qa lock")
+	}
+
+	if got, _ := rules.FindNextSlot("qa", "web", 90*time.Minute, existingEvents); got.Sub(now.Add(2*time.Hour)).Abs() > time.Second {
+		t.Errorf("FindNextSlot() = %v, want end of the lock %v", got, now.Add(2*time.Hour))
+	}
+}
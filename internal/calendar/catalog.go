@@ -0,0 +1,188 @@
+package calendar
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/yossigruner/SlotBot/internal/domain"
+)
+
+// CatalogEntry describes an env or a service: what it is, where to find
+// it, who owns it and what else it may be called
+type CatalogEntry struct {
+	Name        string // Canonical, lower-cased
+	Description string
+	URL         string
+	Team        string   // Owning team
+	Aliases     []string // Other names it may be booked by, lower-cased
+}
+
+// EnvCatalog is an env's catalog entry along with its services'
+type EnvCatalog struct {
+	CatalogEntry
+	Services []CatalogEntry // In the order listed; empty when any service may be booked
+}
+
+// Catalog returns every env in the policy with its services, sorted by name
+func (r *Rules) Catalog() []EnvCatalog {
+	envs := make([]EnvCatalog, 0, len(r.policy.Envs))
+	for _, name := range r.policy.EnvNames() {
+		env := r.policy.Envs[name].Catalog
+		env.Name = name
+		envs = append(envs, env)
+	}
+	return envs
+}
+
+// ResolveEnv returns the canonical name of the env called name or one of
+// its aliases. An unknown env is a *PolicyError suggesting close names.
+func (r *Rules) ResolveEnv(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if _, ok := r.policy.Envs[name]; ok {
+		return name, nil
+	}
+	for env, p := range r.policy.Envs {
+		if slices.Contains(p.Catalog.Aliases, name) {
+			return env, nil
+		}
+	}
+	return "", r.policy.unknownEnv(name)
+}
+
+// ResolveService returns the canonical name of the service in env called
+// name or one of its aliases. Envs without a service list take any name,
+// lower-cased. An unknown service is a *PolicyError suggesting close names.
+func (r *Rules) ResolveService(env, name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	p, ok := r.policy.Envs[strings.ToLower(env)]
+	if !ok || len(p.Services) == 0 || name == domain.AllServices || slices.Contains(p.Services, name) {
+		return name, nil
+	}
+	for _, svc := range p.Catalog.Services {
+		if slices.Contains(svc.Aliases, name) {
+			return svc.Name, nil
+		}
+	}
+	return "", unknownService(strings.ToLower(env), p, name)
+}
+
+// unknownEnv reports that there is no env called name
+func (p *Policy) unknownEnv(name string) *PolicyError {
+	names := make(map[string]string)
+	for env, e := range p.Envs {
+		names[env] = env
+		for _, alias := range e.Catalog.Aliases {
+			names[alias] = env
+		}
+	}
+	if matches := suggest(name, names); len(matches) > 0 {
+		return &PolicyError{Rule: "envs", Msg: fmt.Sprintf("invalid environment: %s. Did you mean %s?", name, orList(matches))}
+	}
+	return &PolicyError{Rule: "envs", Msg: fmt.Sprintf("invalid environment: %s. Must be one of %s", name, strings.Join(p.EnvNames(), ", "))}
+}
+
+// unknownService reports that env has no service called name
+func unknownService(name string, env EnvPolicy, service string) *PolicyError {
+	names := make(map[string]string)
+	for _, svc := range env.Catalog.Services {
+		names[svc.Name] = svc.Name
+		for _, alias := range svc.Aliases {
+			names[alias] = svc.Name
+		}
+	}
+	rule := "envs." + name + ".services"
+	if matches := suggest(strings.ToLower(service), names); len(matches) > 0 {
+		return &PolicyError{Rule: rule, Msg: fmt.Sprintf("%s has no service %s. Did you mean %s?", name, service, orList(matches))}
+	}
+	return &PolicyError{Rule: rule, Msg: fmt.Sprintf("%s has no service %s. Must be one of %s", name, service, strings.Join(env.Services, ", "))}
+}
+
+// suggest returns the canonical names whose name or alias in names is close
+// to name: a typo away, about one per three letters, or starting with it or
+// the other way round. The closest come first.
+func suggest(name string, names map[string]string) []string {
+	typos := max(1, len(name)/3)
+	best := make(map[string]int) // Canonical name -> closest distance
+	for candidate, canonical := range names {
+		d := editDistance(name, candidate)
+		if d > typos && !(min(len(name), len(candidate)) >= 3 && (strings.HasPrefix(name, candidate) || strings.HasPrefix(candidate, name))) {
+			continue
+		}
+		if prev, ok := best[canonical]; !ok || d < prev {
+			best[canonical] = d
+		}
+	}
+
+	matches := make([]string, 0, len(best))
+	for canonical := range best {
+		matches = append(matches, canonical)
+	}
+	slices.SortFunc(matches, func(a, b string) int {
+		if best[a] != best[b] {
+			return best[a] - best[b]
+		}
+		return strings.Compare(a, b)
+	})
+	return matches
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	prev := make([]int, len(br)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ar); i++ {
+		cur := make([]int, len(br)+1)
+		cur[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(br)]
+}
+
+// orList joins names as "a", "a or b" or "a, b or c"
+func orList(names []string) string {
+	if len(names) == 1 {
+		return names[0]
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " or " + names[len(names)-1]
+}
+
+// parseAliases lower-cases aliases and checks they are usable as names
+func parseAliases(rule string, raw []string) ([]string, error) {
+	var aliases []string
+	for _, alias := range raw {
+		alias = strings.ToLower(strings.TrimSpace(alias))
+		if alias == "" || alias == domain.AllServices || alias == "all" || strings.ContainsAny(alias, ", ") {
+			return nil, fmt.Errorf("%s: invalid alias %q", rule, alias)
+		}
+		aliases = append(aliases, alias)
+	}
+	return aliases, nil
+}
+
+// checkEnvAliases makes sure every env name and alias means one env
+func checkEnvAliases(envs map[string]EnvPolicy) error {
+	for name, env := range envs {
+		for _, alias := range env.Catalog.Aliases {
+			for other, o := range envs {
+				if other == name {
+					continue
+				}
+				if alias == other || slices.Contains(o.Catalog.Aliases, alias) {
+					return fmt.Errorf("envs.%s.aliases: %q also names %s", name, alias, other)
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package calendar
+
+import (
+	"strings"
+	"testing"
+)
+
+const testCatalog = `
+envs:
+  staging:
+    description: Pre-production
+    aliases: [stg, Stage]
+    services:
+      api:
+        description: Public API
+        url: https://api.staging.example.com
+        team: backend
+        aliases: [api-server]
+      web: {}
+      worker: {}
+  qa: {}
+`
+
+func TestParseCatalog(t *testing.T) {
+	p, err := ParsePolicy([]byte(testCatalog))
+	if err != nil {
+		t.Fatalf("ParsePolicy() error = %v", err)
+	}
+	rules := NewRules(p, nil, nil)
+
+	envs := rules.Catalog()
+	if len(envs) != 2 || envs[0].Name != "qa" || envs[1].Name != "staging" {
+		t.Fatalf("Catalog() = %+v, want qa and staging", envs)
+	}
+	staging := envs[1]
+	if staging.Description != "Pre-production" || strings.Join(staging.Aliases, ",") != "stg,stage" {
+		t.Errorf("staging = %+v, want its description and lower-cased aliases", staging.CatalogEntry)
+	}
+	if len(staging.Services) != 3 || staging.Services[0].Name != "api" || staging.Services[0].Team != "backend" {
+		t.Errorf("staging services = %+v, want api, web and worker in order", staging.Services)
+	}
+	if got := strings.Join(p.Envs["staging"].Services, ","); got != "api,web,worker" {
+		t.Errorf("staging Services = %s, want api,web,worker", got)
+	}
+}
+
+func TestResolveNames(t *testing.T) {
+	p, err := ParsePolicy([]byte(testCatalog))
+	if err != nil {
+		t.Fatalf("ParsePolicy() error = %v", err)
+	}
+	rules := NewRules(p, nil, nil)
+
+	tests := []struct {
+		name    string
+		env     string
+		service string
+		want    string
+	}{
+		{"Canonical", "staging", "api", "staging/api"},
+		{"Aliases", "STG", "API-Server", "staging/api"},
+		{"Lock", "stage", "*", "staging/*"},
+		{"Any service without a list", "qa", "Anything", "qa/anything"},
+		{"Unknown env suggested", "stagin", "api", "invalid environment: stagin. Did you mean staging?"},
+		{"Unknown env listed", "prod", "api", "invalid environment: prod. Must be one of qa, staging"},
+		{"Typo suggested", "staging", "apis", "staging has no service apis. Did you mean api?"},
+		{"Prefix suggested", "staging", "work", "Did you mean worker?"},
+		{"Unknown service listed", "staging", "db", "staging has no service db. Must be one of api, web, worker (policy rule envs.staging.services)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			env, err := rules.ResolveEnv(tt.env)
+			if err == nil {
+				var svc string
+				svc, err = rules.ResolveService(env, tt.service)
+				got = env + "/" + svc
+			}
+			if err != nil {
+				got = err.Error()
+			}
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("resolve(%s, %s) = %q, want it to contain %q", tt.env, tt.service, got, tt.want)
+			}
+		})
+	}
+}
@@ -2,8 +2,10 @@ package calendar
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
 	"strings"
 	"time"
@@ -11,6 +13,7 @@ import (
 	"github.com/yossigruner/SlotBot/internal/config"
 	"github.com/yossigruner/SlotBot/internal/domain"
 	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/option"
 )
 
@@ -51,56 +54,188 @@ func NewClient(ctx context.Context, cfg *config.Config) (*Client, error) {
 }
 
 func (c *Client) ListEvents(ctx context.Context, start, end time.Time) ([]domain.Event, error) {
-	events, err := c.srv.Events.List(c.calendarID).
+	call := c.srv.Events.List(c.calendarID).
 		ShowDeleted(false).
 		SingleEvents(true).
 		TimeMin(start.Format(time.RFC3339)).
 		TimeMax(end.Format(time.RFC3339)).
-		OrderBy("startTime").
-		Do()
+		OrderBy("startTime")
+
+	var domainEvents []domain.Event
+	skipped := 0
+	err := call.Pages(ctx, func(page *calendar.Events) error {
+		for _, item := range page.Items {
+			event, err := eventFromItem(item, c.timezone)
+			if err != nil {
+				slog.Warn("Skipping calendar event", "event_id", item.Id, "summary", item.Summary, "reason", err)
+				skipped++
+				continue
+			}
+			domainEvents = append(domainEvents, event)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("unable to retrieve next ten of the user's events: %w", err)
+		return nil, fmt.Errorf("unable to list events: %w", err)
 	}
 
-	var domainEvents []domain.Event
-	for _, item := range events.Items {
-		// Parse title: env | service | jira | holder
-		parts := strings.Split(item.Summary, "|")
-		if len(parts) < 2 {
-			continue // Skip malformed events
+	if skipped > 0 {
+		slog.Warn("Some calendar events could not be parsed and are ignored for bookings",
+			"skipped", skipped, "listed", len(domainEvents))
+	}
+
+	return domainEvents, nil
+}
+
+// eventFromItem converts a Calendar API event into a domain.Event. All-day
+// events cover whole days in loc.
+func eventFromItem(item *calendar.Event, loc *time.Location) (domain.Event, error) {
+	meta, ok := parseEventMetadata(item)
+	if !ok {
+		return domain.Event{}, errors.New("no booking properties and summary is not in 'env | service | ...' form")
+	}
+
+	startTime, err := parseEventTime(item.Start, loc)
+	if err != nil {
+		return domain.Event{}, fmt.Errorf("invalid start: %w", err)
+	}
+	endTime, err := parseEventTime(item.End, loc)
+	if err != nil {
+		return domain.Event{}, fmt.Errorf("invalid end: %w", err)
+	}
+
+	return domain.Event{
+		ID:         item.Id,
+		Title:      item.Summary,
+		StartTime:  startTime,
+		EndTime:    endTime,
+		Env:        meta.Env,
+		Service:    meta.Service,
+		Link:       item.HtmlLink,
+		JiraTicket: meta.JiraTicket,
+		User:       meta.User,
+		UserID:     meta.UserID,
+	}, nil
+}
+
+// ListBlackouts reads the blackouts in [start, end) from another calendar,
+// whose events are titled "<env>[,<env>...]: <reason>". "all" or "*", or a
+// title without a colon, blacks out every env.
+func (c *Client) ListBlackouts(ctx context.Context, calendarID string, start, end time.Time) ([]domain.Blackout, error) {
+	call := c.srv.Events.List(calendarID).
+		ShowDeleted(false).
+		SingleEvents(true).
+		TimeMin(start.Format(time.RFC3339)).
+		TimeMax(end.Format(time.RFC3339)).
+		OrderBy("startTime")
+
+	var blackouts []domain.Blackout
+	err := call.Pages(ctx, func(page *calendar.Events) error {
+		for _, item := range page.Items {
+			b, err := blackoutFromItem(item, c.timezone)
+			if err != nil {
+				slog.Warn("Skipping blackout calendar event", "event_id", item.Id, "summary", item.Summary, "reason", err)
+				continue
+			}
+			blackouts = append(blackouts, b)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list blackouts: %w", err)
+	}
+	return blackouts, nil
+}
+
+// blackoutFromItem converts a blackout calendar event. All-day events cover
+// whole days in loc.
+func blackoutFromItem(item *calendar.Event, loc *time.Location) (domain.Blackout, error) {
+	startTime, err := parseEventTime(item.Start, loc)
+	if err != nil {
+		return domain.Blackout{}, fmt.Errorf("invalid start: %w", err)
+	}
+	endTime, err := parseEventTime(item.End, loc)
+	if err != nil {
+		return domain.Blackout{}, fmt.Errorf("invalid end: %w", err)
+	}
+
+	b := domain.Blackout{Start: startTime, End: endTime, Reason: strings.TrimSpace(item.Summary)}
+	if envs, reason, ok := strings.Cut(item.Summary, ":"); ok {
+		b.Reason = strings.TrimSpace(reason)
+		for _, env := range strings.Split(envs, ",") {
+			env = strings.ToLower(strings.TrimSpace(env))
+			if env == "all" || env == "*" {
+				b.Envs = nil
+				break
+			}
+			if env != "" {
+				b.Envs = append(b.Envs, env)
+			}
 		}
+	}
+	if b.Reason == "" {
+		return domain.Blackout{}, errors.New("no reason in summary")
+	}
+	return b, nil
+}
 
-		env := strings.TrimSpace(strings.ToLower(parts[0]))
-		service := strings.TrimSpace(strings.ToLower(parts[1]))
+// parseEventTime reads a timed or all-day event boundary. For all-day events
+// the Calendar API uses exclusive end dates, so midnight of the date is
+// correct for both start and end.
+func parseEventTime(t *calendar.EventDateTime, loc *time.Location) (time.Time, error) {
+	switch {
+	case t == nil:
+		return time.Time{}, errors.New("missing time")
+	case t.DateTime != "":
+		return time.Parse(time.RFC3339, t.DateTime)
+	case t.Date != "":
+		return time.ParseInLocation(time.DateOnly, t.Date, loc)
+	default:
+		return time.Time{}, errors.New("neither dateTime nor date is set")
+	}
+}
 
-		startTime, _ := time.Parse(time.RFC3339, item.Start.DateTime)
-		endTime, _ := time.Parse(time.RFC3339, item.End.DateTime)
+func (c *Client) CreateEvent(ctx context.Context, booking domain.Booking) (*domain.Event, error) {
+	// The booking ID doubles as the event ID so bookings can be addressed directly
+	event := c.toCalendarEvent(newBookingID(), booking)
+	event.Id = event.ExtendedProperties.Private[propBookingID]
 
-		domainEvents = append(domainEvents, domain.Event{
-			Title:     item.Summary,
-			StartTime: startTime,
-			EndTime:   endTime,
-			Env:       env,
-			Service:   service,
-		})
+	createdEvent, err := c.srv.Events.Insert(c.calendarID, event).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("unable to create event: %w", err)
 	}
 
-	return domainEvents, nil
+	return c.toDomainEvent(createdEvent, booking), nil
+}
+
+func (c *Client) UpdateEvent(ctx context.Context, id string, booking domain.Booking) (*domain.Event, error) {
+	updatedEvent, err := c.srv.Events.Patch(c.calendarID, id, c.toCalendarEvent(id, booking)).Context(ctx).Do()
+	if err != nil {
+		if isNotFound(err) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("unable to update event: %w", err)
+	}
+
+	return c.toDomainEvent(updatedEvent, booking), nil
 }
 
-func (c *Client) CreateEvent(ctx context.Context, booking domain.Booking) (string, error) {
-	summary := fmt.Sprintf("%s | %s | %s | %s",
-		strings.ToLower(booking.Env),
-		strings.ToLower(booking.Service),
-		strings.ToUpper(booking.JiraTicket),
-		booking.User,
-	)
+func (c *Client) DeleteEvent(ctx context.Context, id string) error {
+	if err := c.srv.Events.Delete(c.calendarID, id).Context(ctx).Do(); err != nil {
+		if isNotFound(err) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("unable to delete event: %w", err)
+	}
 
-	description := "Managed by Env Booking Bot"
+	return nil
+}
 
+func (c *Client) toCalendarEvent(id string, booking domain.Booking) *calendar.Event {
 	event := &calendar.Event{
-		Summary:     summary,
-		Description: description,
+		Summary:            eventSummary(booking),
+		Description:        "Managed by Env Booking Bot",
+		ExtendedProperties: bookingProperties(id, booking),
 		Start: &calendar.EventDateTime{
 			DateTime: booking.StartTime.Format(time.RFC3339),
 			TimeZone: c.timezone.String(),
@@ -111,10 +246,26 @@ func (c *Client) CreateEvent(ctx context.Context, booking domain.Booking) (strin
 		},
 	}
 
-	createdEvent, err := c.srv.Events.Insert(c.calendarID, event).Do()
-	if err != nil {
-		return "", fmt.Errorf("unable to create event: %w", err)
+	// Recurring bookings become recurring events; ListEvents expands them into instances
+	if rule := RRule(booking); rule != "" {
+		event.Recurrence = []string{rule}
 	}
 
-	return createdEvent.HtmlLink, nil
+	return event
+}
+
+func (c *Client) toDomainEvent(item *calendar.Event, booking domain.Booking) *domain.Event {
+	event := bookingEvent(item.Id, booking)
+	event.Title = item.Summary
+	event.Link = item.HtmlLink
+	return &event
+}
+
+// isNotFound reports whether err is a Calendar API "not found" or "gone" response.
+func isNotFound(err error) bool {
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Code == http.StatusNotFound || apiErr.Code == http.StatusGone
+	}
+	return false
 }
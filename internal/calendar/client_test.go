@@ -0,0 +1,118 @@
+package calendar
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+func TestEventFromItem(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Jerusalem")
+	if err != nil {
+		t.Fatalf("LoadLocation() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		item      *calendar.Event
+		wantStart time.Time
+		wantEnd   time.Time
+		wantErr   bool
+	}{
+		{
+			name: "Timed event",
+			item: &calendar.Event{
+				Summary: "staging | api | PROJ-1 | alice",
+				Start:   &calendar.EventDateTime{DateTime: "2030-01-07T10:00:00Z"},
+				End:     &calendar.EventDateTime{DateTime: "2030-01-07T11:00:00Z"},
+			},
+			wantStart: time.Date(2030, 1, 7, 10, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2030, 1, 7, 11, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "All-day event covers the day in the configured timezone",
+			item: &calendar.Event{
+				Summary: "qa | db | PROJ-2 | blocked",
+				Start:   &calendar.EventDateTime{Date: "2030-01-07"},
+				End:     &calendar.EventDateTime{Date: "2030-01-08"},
+			},
+			wantStart: time.Date(2030, 1, 7, 0, 0, 0, 0, loc),
+			wantEnd:   time.Date(2030, 1, 8, 0, 0, 0, 0, loc),
+		},
+		{
+			name: "Unparseable summary",
+			item: &calendar.Event{
+				Summary: "Team lunch",
+				Start:   &calendar.EventDateTime{DateTime: "2030-01-07T10:00:00Z"},
+				End:     &calendar.EventDateTime{DateTime: "2030-01-07T11:00:00Z"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Invalid start time",
+			item: &calendar.Event{
+				Summary: "staging | api | PROJ-1 | alice",
+				Start:   &calendar.EventDateTime{DateTime: "tomorrow"},
+				End:     &calendar.EventDateTime{DateTime: "2030-01-07T11:00:00Z"},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := eventFromItem(tt.item, loc)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("eventFromItem() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !got.StartTime.Equal(tt.wantStart) || !got.EndTime.Equal(tt.wantEnd) {
+				t.Errorf("eventFromItem() = %v - %v, want %v - %v", got.StartTime, got.EndTime, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestBlackoutFromItem(t *testing.T) {
+	item := func(summary string) *calendar.Event {
+		return &calendar.Event{
+			Summary: summary,
+			Start:   &calendar.EventDateTime{Date: "2030-01-07"},
+			End:     &calendar.EventDateTime{Date: "2030-01-08"},
+		}
+	}
+
+	tests := []struct {
+		summary    string
+		wantEnvs   string
+		wantReason string
+		wantErr    bool
+	}{
+		{"Staging, qa: DB migration", "staging,qa", "DB migration", false},
+		{"all: Release freeze", "", "Release freeze", false},
+		{"Company offsite", "", "Company offsite", false},
+		{"qa:", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.summary, func(t *testing.T) {
+			got, err := blackoutFromItem(item(tt.summary), time.UTC)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("blackoutFromItem() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if envs := strings.Join(got.Envs, ","); envs != tt.wantEnvs || got.Reason != tt.wantReason {
+				t.Errorf("blackoutFromItem() = %v %q, want %v %q", envs, got.Reason, tt.wantEnvs, tt.wantReason)
+			}
+			if !got.Start.Equal(time.Date(2030, 1, 7, 0, 0, 0, 0, time.UTC)) || !got.End.Equal(time.Date(2030, 1, 8, 0, 0, 0, 0, time.UTC)) {
+				t.Errorf("blackoutFromItem() = %v - %v, want all of 2030-01-07", got.Start, got.End)
+			}
+		})
+	}
+}
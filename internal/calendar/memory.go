@@ -0,0 +1,86 @@
+package calendar
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yossigruner/SlotBot/internal/domain"
+)
+
+// MemoryStore is an in-process BookingStore. Bookings are lost on restart.
+type MemoryStore struct {
+	mu     sync.Mutex
+	events map[string]domain.Event
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		events: make(map[string]domain.Event),
+	}
+}
+
+func (m *MemoryStore) ListEvents(ctx context.Context, start, end time.Time) ([]domain.Event, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var events []domain.Event
+	for _, e := range m.events {
+		if e.StartTime.Before(end) && e.EndTime.After(start) {
+			events = append(events, e)
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].StartTime.Before(events[j].StartTime)
+	})
+
+	return events, nil
+}
+
+func (m *MemoryStore) CreateEvent(ctx context.Context, booking domain.Booking) (*domain.Event, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := newBookingID()
+	for _, event := range occurrenceEvents(id, booking) {
+		m.events[event.ID] = event
+	}
+
+	event := bookingEvent(id, booking)
+	return &event, nil
+}
+
+func (m *MemoryStore) UpdateEvent(ctx context.Context, id string, booking domain.Booking) (*domain.Event, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.events[id]; !ok {
+		return nil, ErrNotFound
+	}
+
+	event := bookingEvent(id, booking)
+	m.events[id] = event
+
+	return &event, nil
+}
+
+func (m *MemoryStore) DeleteEvent(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Deleting a recurring booking's ID removes every occurrence
+	found := false
+	for eventID := range m.events {
+		if IsOccurrenceOf(eventID, id) {
+			delete(m.events, eventID)
+			found = true
+		}
+	}
+
+	if !found {
+		return ErrNotFound
+	}
+	return nil
+}
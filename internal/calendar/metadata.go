@@ -0,0 +1,89 @@
+package calendar
+
+import (
+	"strings"
+
+	"github.com/yossigruner/SlotBot/internal/domain"
+	"google.golang.org/api/calendar/v3"
+)
+
+// Keys of the private extended properties the bot stores on each event
+const (
+	propEnv         = "env"
+	propService     = "service"
+	propJiraTicket  = "jira"
+	propSlackUserID = "slack_user_id"
+	propBookingID   = "booking_id"
+)
+
+// eventMetadata is the booking information recovered from a calendar event.
+type eventMetadata struct {
+	BookingID  string
+	Env        string
+	Service    string
+	JiraTicket string
+	User       string
+	UserID     string
+}
+
+func bookingProperties(id string, booking domain.Booking) *calendar.EventExtendedProperties {
+	private := map[string]string{
+		propBookingID:  id,
+		propEnv:        strings.ToLower(booking.Env),
+		propService:    strings.ToLower(booking.Service),
+		propJiraTicket: strings.ToUpper(booking.JiraTicket),
+	}
+	if booking.UserID != "" {
+		private[propSlackUserID] = booking.UserID
+	}
+
+	return &calendar.EventExtendedProperties{Private: private}
+}
+
+// parseEventMetadata reads the booking details from the event's private
+// extended properties, falling back to the "env | service | jira | holder"
+// summary for events created before properties were written.
+func parseEventMetadata(item *calendar.Event) (eventMetadata, bool) {
+	meta := parseSummary(item.Summary)
+
+	if item.ExtendedProperties != nil && item.ExtendedProperties.Private != nil {
+		props := item.ExtendedProperties.Private
+		if props[propEnv] != "" && props[propService] != "" {
+			meta.Env = props[propEnv]
+			meta.Service = props[propService]
+			meta.JiraTicket = props[propJiraTicket]
+			meta.UserID = props[propSlackUserID]
+			meta.BookingID = props[propBookingID]
+		}
+	}
+
+	return meta, meta.Env != "" && meta.Service != ""
+}
+
+// parseSummary parses a title in the form: env | service | jira | holder
+func parseSummary(summary string) eventMetadata {
+	parts := strings.Split(summary, "|")
+	if len(parts) < 2 {
+		return eventMetadata{}
+	}
+
+	meta := eventMetadata{
+		Env:     strings.TrimSpace(strings.ToLower(parts[0])),
+		Service: strings.TrimSpace(strings.ToLower(parts[1])),
+	}
+	if len(parts) > 2 {
+		meta.JiraTicket = strings.TrimSpace(strings.ToUpper(parts[2]))
+	}
+	if len(parts) > 3 {
+		meta.User = strings.TrimSpace(parts[3])
+	}
+
+	return meta
+}
+
+// hasBookingProperties reports whether the event already carries the bot's extended properties.
+func hasBookingProperties(item *calendar.Event) bool {
+	return item.ExtendedProperties != nil &&
+		item.ExtendedProperties.Private != nil &&
+		item.ExtendedProperties.Private[propBookingID] != ""
+}
@@ -0,0 +1,68 @@
+package calendar
+
+import (
+	"testing"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+func TestParseEventMetadata(t *testing.T) {
+	tests := []struct {
+		name   string
+		item   *calendar.Event
+		want   eventMetadata
+		wantOK bool
+	}{
+		{
+			name: "Summary only",
+			item: &calendar.Event{Summary: "Staging | API | proj-1 | alice"},
+			want: eventMetadata{
+				Env:        "staging",
+				Service:    "api",
+				JiraTicket: "PROJ-1",
+				User:       "alice",
+			},
+			wantOK: true,
+		},
+		{
+			name: "Properties win over renamed summary",
+			item: &calendar.Event{
+				Summary: "Release testing (alice)",
+				ExtendedProperties: &calendar.EventExtendedProperties{
+					Private: map[string]string{
+						propBookingID:   "abc123",
+						propEnv:         "qa",
+						propService:     "web",
+						propJiraTicket:  "PROJ-2",
+						propSlackUserID: "U123",
+					},
+				},
+			},
+			want: eventMetadata{
+				BookingID:  "abc123",
+				Env:        "qa",
+				Service:    "web",
+				JiraTicket: "PROJ-2",
+				UserID:     "U123",
+			},
+			wantOK: true,
+		},
+		{
+			name:   "Malformed summary without properties",
+			item:   &calendar.Event{Summary: "Team lunch"},
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseEventMetadata(tt.item)
+			if ok != tt.wantOK {
+				t.Fatalf("parseEventMetadata() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && got != tt.want {
+				t.Errorf("parseEventMetadata() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,548 @@
+package calendar
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yossigruner/SlotBot/internal/config"
+	"github.com/yossigruner/SlotBot/internal/domain"
+	"gopkg.in/yaml.v3"
+)
+
+// Policy is the rules bookings must follow: which envs and services exist,
+// how long and how far ahead they may be booked, and by whom. It is loaded
+// from a YAML or JSON file; see policy.example.yaml.
+type Policy struct {
+	Envs   map[string]EnvPolicy // Keyed by lower-cased env name
+	Groups map[string][]string  // Group name -> Slack user IDs or names
+
+	blackoutRules []blackoutRule
+}
+
+// EnvPolicy is the rules for one env. Zero values mean no limit.
+type EnvPolicy struct {
+	Services     []string         // Bookable services; empty means any
+	Catalog      EnvCatalog       // Descriptions, owners and aliases of the env and its services
+	MinDuration  time.Duration    // Shortest booking
+	MaxDuration  time.Duration    // Longest booking
+	Granularity  time.Duration    // Starts and durations must be multiples of this
+	MaxDaysAhead int              // How many days ahead a booking may start
+	Users        []string         // Slack user IDs or names allowed to book; with Groups empty means anyone
+	Groups       []string         // Groups allowed to book
+	Approvers    []string         // Slack user IDs who may approve urgent bookings that displace others
+	Quota        Quota            // How much each user may hold
+	TeamQuotas   map[string]Quota // How much each Slack user group may hold, keyed by handle
+}
+
+// PolicyError is a booking that breaks a policy rule. Rule names the rule
+// as it is written in the policy file, e.g. "envs.staging.max_duration".
+type PolicyError struct {
+	Rule string
+	Msg  string
+}
+
+func (e *PolicyError) Error() string {
+	return fmt.Sprintf("%s (policy rule %s)", e.Msg, e.Rule)
+}
+
+// DefaultPolicy is used when no policy file is configured
+func DefaultPolicy() *Policy {
+	env := EnvPolicy{MinDuration: 5 * time.Minute, MaxDuration: 2 * time.Hour}
+	return &Policy{
+		Envs: map[string]EnvPolicy{"staging": env, "qa": env, "demo": env},
+	}
+}
+
+// EnvNames returns the env names the policy allows, sorted
+func (p *Policy) EnvNames() []string {
+	names := make([]string, 0, len(p.Envs))
+	for name := range p.Envs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// policyFile is the on-disk form of a Policy. Fields left out of an env
+// are taken from defaults.
+type policyFile struct {
+	Defaults  envPolicyFile            `yaml:"defaults"`
+	Envs      map[string]envPolicyFile `yaml:"envs"`
+	Groups    map[string][]string      `yaml:"groups"`
+	Blackouts []blackoutFile           `yaml:"blackouts"`
+}
+
+// blackoutFile is a blackout entry: either days and time for a weekly
+// window, or from and to for a one-off one
+type blackoutFile struct {
+	Envs     []string `yaml:"envs"`
+	Days     string   `yaml:"days"`
+	Time     string   `yaml:"time"`
+	From     string   `yaml:"from"`
+	To       string   `yaml:"to"`
+	Timezone string   `yaml:"timezone"`
+	Reason   string   `yaml:"reason"`
+}
+
+type envPolicyFile struct {
+	Description  string               `yaml:"description"`
+	URL          string               `yaml:"url"`
+	Team         string               `yaml:"team"`
+	Aliases      []string             `yaml:"aliases"`
+	Services     servicesFile         `yaml:"services"`
+	MinDuration  string               `yaml:"min_duration"`
+	MaxDuration  string               `yaml:"max_duration"`
+	Granularity  string               `yaml:"granularity"`
+	MaxDaysAhead *int                 `yaml:"max_days_ahead"`
+	Users        []string             `yaml:"users"`
+	Groups       []string             `yaml:"groups"`
+	Approvers    []string             `yaml:"approvers"`
+	Quota        *quotaFile           `yaml:"quota"`
+	TeamQuotas   map[string]quotaFile `yaml:"team_quotas"`
+}
+
+// servicesFile is a list of service names, or a map from each name to its
+// catalog entry
+type servicesFile []serviceFile
+
+type serviceFile struct {
+	Name        string   `yaml:"-"`
+	Description string   `yaml:"description"`
+	URL         string   `yaml:"url"`
+	Team        string   `yaml:"team"`
+	Aliases     []string `yaml:"aliases"`
+}
+
+func (s *servicesFile) UnmarshalYAML(node *yaml.Node) error {
+	*s = servicesFile{}
+	switch node.Kind {
+	case yaml.SequenceNode:
+		var names []string
+		if err := node.Decode(&names); err != nil {
+			return err
+		}
+		for _, name := range names {
+			*s = append(*s, serviceFile{Name: name})
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			var svc serviceFile
+			if err := node.Content[i+1].Decode(&svc); err != nil {
+				return err
+			}
+			svc.Name = node.Content[i].Value
+			*s = append(*s, svc)
+		}
+	default:
+		return fmt.Errorf("line %d: services must be a list of names or a map of names to descriptions", node.Line)
+	}
+	return nil
+}
+
+type quotaFile struct {
+	PerDay     string `yaml:"per_day"`
+	PerWeek    string `yaml:"per_week"`
+	Concurrent int    `yaml:"concurrent"`
+}
+
+// LoadPolicy reads and checks a policy file
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read policy: %w", err)
+	}
+	p, err := ParsePolicy(data)
+	if err != nil {
+		return nil, fmt.Errorf("policy %s: %w", path, err)
+	}
+	return p, nil
+}
+
+// ParsePolicy parses a YAML or JSON policy and checks it is consistent,
+// naming the offending rule in errors.
+func ParsePolicy(data []byte) (*Policy, error) {
+	var file policyFile
+	dec := yaml.NewDecoder(strings.NewReader(string(data)))
+	dec.KnownFields(true)
+	if err := dec.Decode(&file); err != nil {
+		return nil, fmt.Errorf("invalid policy: %w", err)
+	}
+
+	if len(file.Envs) == 0 {
+		return nil, fmt.Errorf("envs: at least one env is required")
+	}
+
+	p := &Policy{
+		Envs:   make(map[string]EnvPolicy, len(file.Envs)),
+		Groups: file.Groups,
+	}
+	for name, members := range file.Groups {
+		if len(members) == 0 {
+			return nil, fmt.Errorf("groups.%s: a group needs at least one member", name)
+		}
+	}
+
+	for name, raw := range file.Envs {
+		env, err := parseEnvPolicy("envs."+name, merge(file.Defaults, raw), file.Groups)
+		if err != nil {
+			return nil, err
+		}
+		key := strings.ToLower(name)
+		if _, dup := p.Envs[key]; dup {
+			return nil, fmt.Errorf("envs.%s: env is listed twice", name)
+		}
+		p.Envs[key] = env
+	}
+	if err := checkEnvAliases(p.Envs); err != nil {
+		return nil, err
+	}
+
+	for i, raw := range file.Blackouts {
+		rule, err := parseBlackout(fmt.Sprintf("blackouts[%d]", i), raw, p.Envs)
+		if err != nil {
+			return nil, err
+		}
+		p.blackoutRules = append(p.blackoutRules, rule)
+	}
+
+	return p, nil
+}
+
+func parseBlackout(rule string, raw blackoutFile, envs map[string]EnvPolicy) (blackoutRule, error) {
+	b := blackoutRule{Reason: strings.TrimSpace(raw.Reason)}
+	if b.Reason == "" {
+		return blackoutRule{}, fmt.Errorf("%s.reason: a blackout needs a reason", rule)
+	}
+
+	for _, env := range raw.Envs {
+		env = strings.ToLower(env)
+		if _, ok := envs[env]; !ok {
+			return blackoutRule{}, fmt.Errorf("%s.envs: unknown env %q", rule, env)
+		}
+		b.Envs = append(b.Envs, env)
+	}
+
+	if raw.Timezone != "" {
+		loc, err := time.LoadLocation(raw.Timezone)
+		if err != nil {
+			return blackoutRule{}, fmt.Errorf("%s.timezone: %w", rule, err)
+		}
+		b.Location = loc
+	}
+
+	weekly := raw.Days != "" || raw.Time != ""
+	oneOff := raw.From != "" || raw.To != ""
+	switch {
+	case weekly && oneOff:
+		return blackoutRule{}, fmt.Errorf("%s: use either days and time, or from and to", rule)
+	case weekly:
+		days := raw.Days
+		if days == "" {
+			days = "daily"
+		}
+		var err error
+		if b.Days, err = config.ParseWeekdays(strings.ToLower(days)); err != nil {
+			return blackoutRule{}, fmt.Errorf("%s.days: %w", rule, err)
+		}
+		startStr, endStr, ok := strings.Cut(raw.Time, "-")
+		if !ok {
+			return blackoutRule{}, fmt.Errorf("%s.time: want HH:MM-HH:MM", rule)
+		}
+		if b.Start, err = config.ParseTimeOfDay(strings.TrimSpace(startStr)); err != nil {
+			return blackoutRule{}, fmt.Errorf("%s.time: %w", rule, err)
+		}
+		if b.End, err = config.ParseTimeOfDay(strings.TrimSpace(endStr)); err != nil {
+			return blackoutRule{}, fmt.Errorf("%s.time: %w", rule, err)
+		}
+		b.Weekly = true
+	case oneOff:
+		var err error
+		if b.From, err = parseBlackoutTime(raw.From, false); err != nil {
+			return blackoutRule{}, fmt.Errorf("%s.from: %w", rule, err)
+		}
+		if b.To, err = parseBlackoutTime(raw.To, true); err != nil {
+			return blackoutRule{}, fmt.Errorf("%s.to: %w", rule, err)
+		}
+		if !b.To.After(b.From) {
+			return blackoutRule{}, fmt.Errorf("%s.to: must be after from", rule)
+		}
+	default:
+		return blackoutRule{}, fmt.Errorf("%s: needs days and time, or from and to", rule)
+	}
+	return b, nil
+}
+
+// parseBlackoutTime parses "YYYY-MM-DD HH:MM" or a date, which means the
+// start of the day, or its end if end is set
+func parseBlackoutTime(s string, end bool) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02 15:04", s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: use YYYY-MM-DD HH:MM or YYYY-MM-DD", s)
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// merge fills the fields env leaves out from defaults
+func merge(defaults, env envPolicyFile) envPolicyFile {
+	if env.Services == nil {
+		env.Services = defaults.Services
+	}
+	if env.Team == "" {
+		env.Team = defaults.Team
+	}
+	if env.MinDuration == "" {
+		env.MinDuration = defaults.MinDuration
+	}
+	if env.MaxDuration == "" {
+		env.MaxDuration = defaults.MaxDuration
+	}
+	if env.Granularity == "" {
+		env.Granularity = defaults.Granularity
+	}
+	if env.MaxDaysAhead == nil {
+		env.MaxDaysAhead = defaults.MaxDaysAhead
+	}
+	if env.Users == nil {
+		env.Users = defaults.Users
+	}
+	if env.Groups == nil {
+		env.Groups = defaults.Groups
+	}
+	if env.Approvers == nil {
+		env.Approvers = defaults.Approvers
+	}
+	if env.Quota == nil {
+		env.Quota = defaults.Quota
+	}
+	if env.TeamQuotas == nil {
+		env.TeamQuotas = defaults.TeamQuotas
+	}
+	return env
+}
+
+func parseEnvPolicy(rule string, raw envPolicyFile, groups map[string][]string) (EnvPolicy, error) {
+	var env EnvPolicy
+	var err error
+
+	if env.MinDuration, err = parsePolicyDuration(rule+".min_duration", raw.MinDuration); err != nil {
+		return EnvPolicy{}, err
+	}
+	if env.MaxDuration, err = parsePolicyDuration(rule+".max_duration", raw.MaxDuration); err != nil {
+		return EnvPolicy{}, err
+	}
+	if env.Granularity, err = parsePolicyDuration(rule+".granularity", raw.Granularity); err != nil {
+		return EnvPolicy{}, err
+	}
+	if env.MaxDuration > 0 && env.MinDuration > env.MaxDuration {
+		return EnvPolicy{}, fmt.Errorf("%s.min_duration: %s is longer than max_duration %s", rule, shortDuration(env.MinDuration), shortDuration(env.MaxDuration))
+	}
+	if env.Granularity > 0 && env.MinDuration > 0 && env.MinDuration%env.Granularity != 0 {
+		return EnvPolicy{}, fmt.Errorf("%s.min_duration: %s is not a multiple of granularity %s", rule, shortDuration(env.MinDuration), shortDuration(env.Granularity))
+	}
+
+	if raw.MaxDaysAhead != nil {
+		if *raw.MaxDaysAhead < 0 {
+			return EnvPolicy{}, fmt.Errorf("%s.max_days_ahead: must not be negative", rule)
+		}
+		env.MaxDaysAhead = *raw.MaxDaysAhead
+	}
+
+	env.Catalog.CatalogEntry = CatalogEntry{
+		Name:        strings.ToLower(strings.TrimPrefix(rule, "envs.")),
+		Description: raw.Description,
+		URL:         raw.URL,
+		Team:        raw.Team,
+	}
+	if env.Catalog.Aliases, err = parseAliases(rule+".aliases", raw.Aliases); err != nil {
+		return EnvPolicy{}, err
+	}
+
+	names := make(map[string]string) // Name or alias -> service
+	for _, raw := range raw.Services {
+		svc := strings.ToLower(strings.TrimSpace(raw.Name))
+		if svc == "" || svc == domain.AllServices || strings.Contains(svc, ",") {
+			return EnvPolicy{}, fmt.Errorf("%s.services: invalid service name %q", rule, svc)
+		}
+		aliases, err := parseAliases(rule+".services."+svc+".aliases", raw.Aliases)
+		if err != nil {
+			return EnvPolicy{}, err
+		}
+		for _, name := range append([]string{svc}, aliases...) {
+			if other, dup := names[name]; dup {
+				return EnvPolicy{}, fmt.Errorf("%s.services: %q names both %s and %s", rule, name, other, svc)
+			}
+			names[name] = svc
+		}
+		env.Services = append(env.Services, svc)
+		env.Catalog.Services = append(env.Catalog.Services, CatalogEntry{
+			Name:        svc,
+			Description: raw.Description,
+			URL:         raw.URL,
+			Team:        raw.Team,
+			Aliases:     aliases,
+		})
+	}
+
+	for _, g := range raw.Groups {
+		if _, ok := groups[g]; !ok {
+			return EnvPolicy{}, fmt.Errorf("%s.groups: unknown group %q", rule, g)
+		}
+	}
+	env.Users = raw.Users
+	env.Groups = raw.Groups
+	env.Approvers = raw.Approvers
+
+	if raw.Quota != nil {
+		if env.Quota, err = parseQuota(rule+".quota", *raw.Quota); err != nil {
+			return EnvPolicy{}, err
+		}
+	}
+	for team, q := range raw.TeamQuotas {
+		quota, err := parseQuota(rule+".team_quotas."+team, q)
+		if err != nil {
+			return EnvPolicy{}, err
+		}
+		if env.TeamQuotas == nil {
+			env.TeamQuotas = make(map[string]Quota)
+		}
+		env.TeamQuotas[strings.TrimPrefix(team, "@")] = quota
+	}
+
+	return env, nil
+}
+
+func parseQuota(rule string, raw quotaFile) (Quota, error) {
+	var q Quota
+	var err error
+	if q.PerDay, err = parsePolicyDuration(rule+".per_day", raw.PerDay); err != nil {
+		return Quota{}, err
+	}
+	if q.PerWeek, err = parsePolicyDuration(rule+".per_week", raw.PerWeek); err != nil {
+		return Quota{}, err
+	}
+	if raw.Concurrent < 0 {
+		return Quota{}, fmt.Errorf("%s.concurrent: must not be negative", rule)
+	}
+	q.Concurrent = raw.Concurrent
+	return q, nil
+}
+
+func parsePolicyDuration(rule, s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("%s: invalid duration %q", rule, s)
+	}
+	return d, nil
+}
+
+// check reports the first rule b breaks, if any
+func (p *Policy) check(b domain.Booking, now time.Time) error {
+	name := strings.ToLower(b.Env)
+	env, ok := p.Envs[name]
+	if !ok {
+		return p.unknownEnv(b.Env)
+	}
+	rule := "envs." + name
+
+	if len(env.Services) > 0 && b.Service != domain.AllServices && !slices.Contains(env.Services, strings.ToLower(b.Service)) {
+		return unknownService(name, env, b.Service)
+	}
+
+	if env.MaxDuration > 0 && b.Duration > env.MaxDuration {
+		return &PolicyError{Rule: rule + ".max_duration", Msg: fmt.Sprintf("maximum booking duration is %s", shortDuration(env.MaxDuration))}
+	}
+	if b.Duration < env.MinDuration {
+		return &PolicyError{Rule: rule + ".min_duration", Msg: fmt.Sprintf("minimum booking duration is %s", shortDuration(env.MinDuration))}
+	}
+
+	if g := env.Granularity; g > 0 {
+		start := b.StartTime
+		sinceMidnight := start.Sub(time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location()))
+		if sinceMidnight%g != 0 || b.Duration%g != 0 {
+			return &PolicyError{Rule: rule + ".granularity", Msg: fmt.Sprintf("bookings in %s must start and last in steps of %s", b.Env, shortDuration(g))}
+		}
+	}
+
+	// A recurring booking's last occurrence is the furthest ahead
+	occurrences := Occurrences(b)
+	if env.MaxDaysAhead > 0 && len(occurrences) > 0 && occurrences[len(occurrences)-1].After(now.AddDate(0, 0, env.MaxDaysAhead)) {
+		return &PolicyError{Rule: rule + ".max_days_ahead", Msg: fmt.Sprintf("%s can be booked at most %d days ahead", b.Env, env.MaxDaysAhead)}
+	}
+
+	if (len(env.Users) > 0 || len(env.Groups) > 0) && !p.allowed(env, b.UserID, b.User) {
+		return &PolicyError{Rule: rule + ".users", Msg: fmt.Sprintf("you are not allowed to book %s", b.Env)}
+	}
+
+	return nil
+}
+
+// allowed reports whether the user is listed in the env's users or groups
+func (p *Policy) allowed(env EnvPolicy, userID, userName string) bool {
+	matches := func(member string) bool {
+		return (userID != "" && member == userID) || (userName != "" && strings.EqualFold(member, userName))
+	}
+
+	if slices.ContainsFunc(env.Users, matches) {
+		return true
+	}
+	for _, g := range env.Groups {
+		if slices.ContainsFunc(p.Groups[g], matches) {
+			return true
+		}
+	}
+	return false
+}
+
+// horizon returns how far ahead env may be booked under the policy, 0 for no limit
+func (p *Policy) horizon(env string) time.Duration {
+	return time.Duration(p.Envs[strings.ToLower(env)].MaxDaysAhead) * 24 * time.Hour
+}
+
+// Horizon returns how far ahead env may be booked, the tighter of the
+// schedule's horizon and the policy's max_days_ahead. 0 means no limit.
+func (r *Rules) Horizon(env string) time.Duration {
+	a, b := r.ScheduleFor(env).Horizon, r.policy.horizon(env)
+	switch {
+	case a == 0:
+		return b
+	case b == 0:
+		return a
+	}
+	return min(a, b)
+}
+
+// Approvers returns who may approve an urgent booking in env displacing
+// others, as Slack user IDs. Without any, the holders of the bookings decide.
+func (r *Rules) Approvers(env string) []string {
+	return r.policy.Envs[strings.ToLower(env)].Approvers
+}
+
+// Granularity returns the step env's bookings must start and last in, 0 for none
+func (r *Rules) Granularity(env string) time.Duration {
+	return r.policy.Envs[strings.ToLower(env)].Granularity
+}
+
+// shortDuration formats d without zero units, e.g. "2h" rather than "2h0m0s"
+func shortDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
@@ -0,0 +1,160 @@
+package calendar
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yossigruner/SlotBot/internal/domain"
+)
+
+const testPolicy = `
+defaults:
+  min_duration: 15m
+  max_duration: 2h
+  granularity: 15m
+  max_days_ahead: 7
+envs:
+  Staging: {}
+  qa:
+    services: [api, Web]
+    max_duration: 4h
+  demo:
+    users: [U1]
+    groups: [sales]
+groups:
+  sales: [U2, dana]
+`
+
+func TestParsePolicy(t *testing.T) {
+	p, err := ParsePolicy([]byte(testPolicy))
+	if err != nil {
+		t.Fatalf("ParsePolicy() error = %v", err)
+	}
+
+	if got := strings.Join(p.EnvNames(), ","); got != "demo,qa,staging" {
+		t.Errorf("EnvNames() = %s, want demo,qa,staging", got)
+	}
+	qa := p.Envs["qa"]
+	if qa.MaxDuration != 4*time.Hour || qa.MinDuration != 15*time.Minute || qa.MaxDaysAhead != 7 {
+		t.Errorf("qa = %+v, want its own max_duration and the default rest", qa)
+	}
+	if strings.Join(qa.Services, ",") != "api,web" {
+		t.Errorf("qa services = %v, want [api web]", qa.Services)
+	}
+}
+
+func TestParsePolicyErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy string
+		want   string
+	}{
+		{"No envs", "groups: {}", "envs:"},
+		{"Unknown field", "envs:\n  qa:\n    max_hours: 2", "max_hours"},
+		{"Bad duration", "envs:\n  qa:\n    max_duration: forever", "envs.qa.max_duration"},
+		{"Min above max", "envs:\n  qa:\n    min_duration: 3h\n    max_duration: 2h", "envs.qa.min_duration"},
+		{"Min not a step", "envs:\n  qa:\n    min_duration: 10m\n    granularity: 15m", "envs.qa.min_duration"},
+		{"Negative days", "envs:\n  qa:\n    max_days_ahead: -1", "envs.qa.max_days_ahead"},
+		{"Lock as service", "envs:\n  qa:\n    services: ['*']", "envs.qa.services"},
+		{"Unknown group", "envs:\n  qa:\n    groups: [ops]", "envs.qa.groups"},
+		{"Empty group", "envs:\n  qa: {}\ngroups:\n  ops: []", "groups.ops"},
+		{"Duplicate env", "envs:\n  qa: {}\n  QA: {}", "listed twice"},
+		{"Services neither list nor map", "envs:\n  qa:\n    services: api", "services must be"},
+		{"Alias names two services", "envs:\n  qa:\n    services:\n      api: {aliases: [web]}\n      web: {}", "envs.qa.services"},
+		{"Alias names two envs", "envs:\n  qa: {aliases: [test]}\n  dev: {aliases: [test]}", "aliases"},
+		{"Alias with a comma", "envs:\n  qa: {aliases: ['a,b']}", "envs.qa.aliases"},
+		{"Blackout without reason", "envs:\n  qa: {}\nblackouts:\n  - days: tue\n    time: 22:00-02:00", "blackouts[0].reason"},
+		{"Blackout of unknown env", "envs:\n  qa: {}\nblackouts:\n  - envs: [prod]\n    from: 2030-01-01\n    to: 2030-01-02\n    reason: x", "blackouts[0].envs"},
+		{"Blackout with bad time", "envs:\n  qa: {}\nblackouts:\n  - time: 22:00\n    reason: x", "blackouts[0].time"},
+		{"Blackout ending first", "envs:\n  qa: {}\nblackouts:\n  - from: 2030-01-02\n    to: 2030-01-01\n    reason: x", "blackouts[0].to"},
+		{"Blackout without window", "envs:\n  qa: {}\nblackouts:\n  - reason: x", "blackouts[0]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParsePolicy([]byte(tt.policy))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParsePolicy() error = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateBookingPolicy(t *testing.T) {
+	p, err := ParsePolicy([]byte(testPolicy))
+	if err != nil {
+		t.Fatalf("ParsePolicy() error = %v", err)
+	}
+	rules := NewRules(p, nil, nil)
+
+	now := time.Now()
+	step := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, 1)
+
+	tests := []struct {
+		name     string
+		booking  domain.Booking
+		wantRule string
+	}{
+		{"Allowed", domain.Booking{Env: "STAGING", Service: "api", StartTime: step, Duration: time.Hour}, ""},
+		{"Unknown env", domain.Booking{Env: "prod", StartTime: step, Duration: time.Hour}, "envs"},
+		{"Unknown service", domain.Booking{Env: "qa", Service: "db", StartTime: step, Duration: time.Hour}, "envs.qa.services"},
+		{"Lock any service", domain.Booking{Env: "qa", Service: domain.AllServices, StartTime: step, Duration: time.Hour}, ""},
+		{"Too long", domain.Booking{Env: "staging", StartTime: step, Duration: 3 * time.Hour}, "envs.staging.max_duration"},
+		{"Longer env limit", domain.Booking{Env: "qa", Service: "api", StartTime: step, Duration: 3 * time.Hour}, ""},
+		{"Too short", domain.Booking{Env: "staging", StartTime: step, Duration: 5 * time.Minute}, "envs.staging.min_duration"},
+		{"Off step start", domain.Booking{Env: "staging", StartTime: step.Add(10 * time.Minute), Duration: time.Hour}, "envs.staging.granularity"},
+		{"Off step duration", domain.Booking{Env: "staging", StartTime: step, Duration: 50 * time.Minute}, "envs.staging.granularity"},
+		{"Too far ahead", domain.Booking{Env: "staging", StartTime: step.AddDate(0, 0, 8), Duration: time.Hour}, "envs.staging.max_days_ahead"},
+		{"Recurring within days ahead", domain.Booking{Env: "staging", StartTime: step, Duration: time.Hour, Recurrence: &domain.Recurrence{Frequency: domain.FrequencyDaily, Until: step.AddDate(0, 0, 5)}}, ""},
+		{"Recurring too far ahead", domain.Booking{Env: "staging", StartTime: step, Duration: time.Hour, Recurrence: &domain.Recurrence{Frequency: domain.FrequencyDaily, Until: step.AddDate(0, 0, 10)}}, "envs.staging.max_days_ahead"},
+		{"Listed user", domain.Booking{Env: "demo", UserID: "U1", StartTime: step, Duration: time.Hour}, ""},
+		{"Group member by name", domain.Booking{Env: "demo", User: "Dana", UserID: "U9", StartTime: step, Duration: time.Hour}, ""},
+		{"Not allowed", domain.Booking{Env: "demo", User: "alice", UserID: "U3", StartTime: step, Duration: time.Hour}, "envs.demo.users"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := rules.ValidateBooking(tt.booking)
+			if tt.wantRule == "" {
+				if err != nil {
+					t.Errorf("ValidateBooking() error = %v, want nil", err)
+				}
+				return
+			}
+
+			var perr *PolicyError
+			if !errors.As(err, &perr) {
+				t.Fatalf("ValidateBooking() error = %v, want a *PolicyError", err)
+			}
+			if perr.Rule != tt.wantRule {
+				t.Errorf("Rule = %s, want %s", perr.Rule, tt.wantRule)
+			}
+			if !strings.Contains(err.Error(), "policy rule "+tt.wantRule) {
+				t.Errorf("Error() = %q, want it to name the rule", err)
+			}
+		})
+	}
+}
+
+func TestHorizon(t *testing.T) {
+	p, err := ParsePolicy([]byte("envs:\n  qa:\n    max_days_ahead: 3\n  demo: {}"))
+	if err != nil {
+		t.Fatalf("ParsePolicy() error = %v", err)
+	}
+	rules := NewRules(p, map[string]domain.Schedule{DefaultSchedule: {Horizon: 5 * 24 * time.Hour}}, nil)
+
+	if got := rules.Horizon("qa"); got != 3*24*time.Hour {
+		t.Errorf("Horizon(qa) = %v, want the policy's 72h", got)
+	}
+	if got := rules.Horizon("demo"); got != 5*24*time.Hour {
+		t.Errorf("Horizon(demo) = %v, want the schedule's 120h", got)
+	}
+}
+
+func TestExamplePolicy(t *testing.T) {
+	if _, err := LoadPolicy("../../policy.example.yaml"); err != nil {
+		t.Errorf("LoadPolicy(policy.example.yaml) error = %v", err)
+	}
+}
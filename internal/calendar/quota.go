@@ -0,0 +1,296 @@
+package calendar
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yossigruner/SlotBot/internal/domain"
+)
+
+// Quota limits how much of an env one user, or all members of a team
+// together, may hold. Zero values mean no limit.
+type Quota struct {
+	PerDay     time.Duration // Booked time per calendar day
+	PerWeek    time.Duration // Booked time per week, Monday to Sunday
+	Concurrent int           // Bookings held at the same time
+}
+
+func (q Quota) isZero() bool {
+	return q == Quota{}
+}
+
+// TeamSource lists teams, keyed by Slack user group handle, with the Slack
+// user IDs of their members.
+type TeamSource interface {
+	TeamMembers(ctx context.Context) map[string][]string
+}
+
+// QuotaUsage is how much of a quota a user or team has booked in env
+type QuotaUsage struct {
+	Env        string
+	Team       string // Empty for the user's own quota
+	Quota      Quota
+	Day        time.Duration // Booked today
+	Week       time.Duration // Booked this week
+	Concurrent int           // Bookings held right now
+}
+
+// CheckQuota reports the first quota the bookings would take their user
+// over, as a *PolicyError saying how much is left. The bookings are made
+// together, by one user, in one env; recurring ones count every occurrence.
+// Bookings with an ID replace the existing event. teams may be nil.
+func (r *Rules) CheckQuota(ctx context.Context, store BookingStore, bookings []domain.Booking, teams TeamSource) error {
+	if len(bookings) == 0 {
+		return nil
+	}
+	b := bookings[0]
+	name := strings.ToLower(b.Env)
+	env := r.policy.Envs[name]
+	if env.Quota.isZero() && len(env.TeamQuotas) == 0 {
+		return nil
+	}
+
+	var added []domain.Event
+	for _, booking := range bookings {
+		for _, start := range Occurrences(booking) {
+			added = append(added, domain.Event{
+				ID:        booking.ID,
+				Env:       name,
+				Service:   booking.Service,
+				StartTime: start,
+				EndTime:   start.Add(booking.Duration),
+				User:      booking.User,
+				UserID:    booking.UserID,
+			})
+		}
+	}
+
+	loc := location(r.ScheduleFor(name))
+	from, _ := weekOf(added[0].StartTime, loc)
+	_, to := weekOf(added[len(added)-1].EndTime, loc)
+	listed, err := store.ListEvents(ctx, from, to)
+	if err != nil {
+		return fmt.Errorf("check quota: %w", err)
+	}
+
+	var existing []domain.Event
+	for _, e := range listed {
+		if strings.EqualFold(e.Env, name) && !slices.ContainsFunc(bookings, func(b domain.Booking) bool { return b.ID != "" && b.ID == e.ID }) {
+			existing = append(existing, e)
+		}
+	}
+
+	rule := "envs." + name
+	mine := func(e domain.Event) bool { return sameUser(e, b.UserID, b.User) }
+	if err := checkQuota(env.Quota, rule+".quota", fmt.Sprintf("your %s quota", name), existing, added, mine, loc); err != nil {
+		return err
+	}
+
+	if len(env.TeamQuotas) == 0 || teams == nil || b.UserID == "" {
+		return nil
+	}
+	members := teams.TeamMembers(ctx)
+	for _, team := range sortedKeys(env.TeamQuotas) {
+		if !slices.Contains(members[team], b.UserID) {
+			continue
+		}
+		inTeam := func(e domain.Event) bool { return slices.Contains(members[team], e.UserID) }
+		if err := checkQuota(env.TeamQuotas[team], rule+".team_quotas."+team, fmt.Sprintf("team %s's %s quota", team, name), existing, added, inTeam, loc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkQuota checks q against the events that count towards it, those in
+// existing that match plus everything added, for every added occurrence
+func checkQuota(q Quota, rule, whose string, existing, added []domain.Event, counts func(domain.Event) bool, loc *time.Location) error {
+	if q.isZero() {
+		return nil
+	}
+
+	var held []domain.Event
+	for _, e := range existing {
+		if counts(e) {
+			held = append(held, e)
+		}
+	}
+	all := append(slices.Clone(held), added...)
+
+	for _, a := range added {
+		if q.PerDay > 0 {
+			start, end := dayOf(a.StartTime, loc)
+			if bookedTime(all, start, end) > q.PerDay {
+				left := max(q.PerDay-bookedTime(held, start, end), 0)
+				return &PolicyError{Rule: rule + ".per_day", Msg: fmt.Sprintf("that goes over %s of %s per day: %s left on %s",
+					whose, shortDuration(q.PerDay), shortDuration(left), start.Format("Mon 02 Jan"))}
+			}
+		}
+		if q.PerWeek > 0 {
+			start, end := weekOf(a.StartTime, loc)
+			if bookedTime(all, start, end) > q.PerWeek {
+				left := max(q.PerWeek-bookedTime(held, start, end), 0)
+				return &PolicyError{Rule: rule + ".per_week", Msg: fmt.Sprintf("that goes over %s of %s per week: %s left in the week of %s",
+					whose, shortDuration(q.PerWeek), shortDuration(left), start.Format("Mon 02 Jan"))}
+			}
+		}
+		if q.Concurrent > 0 && concurrent(all, a.StartTime, a.EndTime) > q.Concurrent {
+			return &PolicyError{Rule: rule + ".concurrent", Msg: fmt.Sprintf("that goes over %s of %d bookings at a time: %d already held then",
+				whose, q.Concurrent, concurrent(held, a.StartTime, a.EndTime))}
+		}
+	}
+	return nil
+}
+
+// QuotaUsages returns the user's usage of every quota that applies to them,
+// per env, at now. The user is matched by ID or name; team quotas need
+// the ID. teams may be nil.
+func (r *Rules) QuotaUsages(ctx context.Context, store BookingStore, userID, userName string, teams TeamSource, now time.Time) ([]QuotaUsage, error) {
+	var members map[string][]string
+	var usages []QuotaUsage
+	for _, name := range r.policy.EnvNames() {
+		env := r.policy.Envs[name]
+		if env.Quota.isZero() && len(env.TeamQuotas) == 0 {
+			continue
+		}
+
+		loc := location(r.ScheduleFor(name))
+		dayStart, dayEnd := dayOf(now, loc)
+		weekStart, weekEnd := weekOf(now, loc)
+		events, err := store.ListEvents(ctx, weekStart, weekEnd)
+		if err != nil {
+			return nil, fmt.Errorf("list quota usage: %w", err)
+		}
+
+		usage := func(team string, q Quota, counts func(domain.Event) bool) QuotaUsage {
+			var held []domain.Event
+			for _, e := range events {
+				if strings.EqualFold(e.Env, name) && counts(e) {
+					held = append(held, e)
+				}
+			}
+			return QuotaUsage{
+				Env:        name,
+				Team:       team,
+				Quota:      q,
+				Day:        bookedTime(held, dayStart, dayEnd),
+				Week:       bookedTime(held, weekStart, weekEnd),
+				Concurrent: concurrent(held, now, now.Add(time.Nanosecond)),
+			}
+		}
+
+		if !env.Quota.isZero() {
+			usages = append(usages, usage("", env.Quota, func(e domain.Event) bool { return sameUser(e, userID, userName) }))
+		}
+
+		if len(env.TeamQuotas) == 0 || teams == nil || userID == "" {
+			continue
+		}
+		if members == nil {
+			members = teams.TeamMembers(ctx)
+		}
+		for _, team := range sortedKeys(env.TeamQuotas) {
+			if slices.Contains(members[team], userID) {
+				usages = append(usages, usage(team, env.TeamQuotas[team], func(e domain.Event) bool { return slices.Contains(members[team], e.UserID) }))
+			}
+		}
+	}
+	return usages, nil
+}
+
+// sameUser reports whether e was booked by the user. Events are matched by
+// Slack user ID when both have one, and by name otherwise: legacy events
+// without an ID, or a user only known by name.
+func sameUser(e domain.Event, userID, userName string) bool {
+	if e.UserID != "" && userID != "" {
+		return e.UserID == userID
+	}
+	return userName != "" && strings.EqualFold(e.User, userName)
+}
+
+// bookedTime is how much of [start, end) each user holds at least one of
+// the events, summed over users
+func bookedTime(events []domain.Event, start, end time.Time) time.Duration {
+	byUser := make(map[string][]domain.Event)
+	for _, e := range events {
+		user := e.UserID
+		if user == "" {
+			user = strings.ToLower(e.User)
+		}
+		byUser[user] = append(byUser[user], e)
+	}
+
+	var total time.Duration
+	for _, held := range byUser {
+		sort.Slice(held, func(i, j int) bool { return held[i].StartTime.Before(held[j].StartTime) })
+
+		// Overlapping events, like several services booked together, count once
+		var covered time.Time
+		for _, e := range held {
+			from, to := e.StartTime, e.EndTime
+			if from.Before(start) {
+				from = start
+			}
+			if to.After(end) {
+				to = end
+			}
+			if from.Before(covered) {
+				from = covered
+			}
+			if to.After(from) {
+				total += to.Sub(from)
+				covered = to
+			}
+		}
+	}
+	return total
+}
+
+// concurrent returns the most events held at once during [start, end)
+func concurrent(events []domain.Event, start, end time.Time) int {
+	points := []time.Time{start}
+	for _, e := range events {
+		if e.StartTime.After(start) && e.StartTime.Before(end) {
+			points = append(points, e.StartTime)
+		}
+	}
+
+	most := 0
+	for _, t := range points {
+		n := 0
+		for _, e := range events {
+			if !e.StartTime.After(t) && e.EndTime.After(t) {
+				n++
+			}
+		}
+		most = max(most, n)
+	}
+	return most
+}
+
+// dayOf returns the calendar day t falls on in loc
+func dayOf(t time.Time, loc *time.Location) (time.Time, time.Time) {
+	t = t.In(loc)
+	start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	return start, start.AddDate(0, 0, 1)
+}
+
+// weekOf returns the Monday-to-Sunday week t falls in in loc
+func weekOf(t time.Time, loc *time.Location) (time.Time, time.Time) {
+	day, _ := dayOf(t, loc)
+	start := day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	return start, start.AddDate(0, 0, 7)
+}
+
+func sortedKeys(m map[string]Quota) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
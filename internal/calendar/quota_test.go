@@ -0,0 +1,148 @@
+package calendar
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yossigruner/SlotBot/internal/domain"
+)
+
+type fakeTeams map[string][]string
+
+func (f fakeTeams) TeamMembers(ctx context.Context) map[string][]string {
+	return f
+}
+
+const quotaPolicy = `
+envs:
+  staging:
+    quota:
+      per_day: 4h
+      per_week: 10h
+      concurrent: 2
+    team_quotas:
+      backend:
+        per_day: 6h
+  qa: {}
+`
+
+func TestCheckQuota(t *testing.T) {
+	p, err := ParsePolicy([]byte(quotaPolicy))
+	if err != nil {
+		t.Fatalf("ParsePolicy() error = %v", err)
+	}
+	rules := NewRules(p, nil, nil)
+
+	// Monday
+	day := time.Date(2030, 1, 7, 0, 0, 0, 0, time.UTC)
+	at := func(days, hour int) time.Time { return day.AddDate(0, 0, days).Add(time.Duration(hour) * time.Hour) }
+
+	ctx := context.Background()
+	store := NewMemoryStore()
+	for _, b := range []domain.Booking{
+		// api and web together count as 2h of staging, not 4h
+		{Env: "staging", Service: "api", StartTime: at(0, 9), Duration: 2 * time.Hour, User: "alice", UserID: "U1"},
+		{Env: "staging", Service: "web", StartTime: at(0, 9), Duration: 2 * time.Hour, User: "alice", UserID: "U1"},
+		{Env: "staging", Service: "api", StartTime: at(1, 9), Duration: 4 * time.Hour, User: "alice", UserID: "U1"},
+		{Env: "staging", Service: "db", StartTime: at(0, 9), Duration: 3 * time.Hour, User: "bob", UserID: "U2"},
+		{Env: "qa", Service: "api", StartTime: at(0, 13), Duration: 8 * time.Hour, User: "alice", UserID: "U1"},
+	} {
+		if _, err := store.CreateEvent(ctx, b); err != nil {
+			t.Fatalf("CreateEvent() error = %v", err)
+		}
+	}
+	existing, _ := store.ListEvents(ctx, at(1, 0), at(2, 0))
+
+	alice := func(service string, start time.Time, d time.Duration) domain.Booking {
+		return domain.Booking{Env: "staging", Service: service, StartTime: start, Duration: d, User: "alice", UserID: "U1"}
+	}
+	weekly := alice("api", at(2, 9), time.Hour)
+	weekly.Recurrence = &domain.Recurrence{Frequency: domain.FrequencyDaily, Until: day.AddDate(0, 0, 6)}
+	extended := alice("api", at(1, 9), 5*time.Hour)
+	extended.ID = existing[0].ID
+
+	tests := []struct {
+		name     string
+		bookings []domain.Booking
+		teams    TeamSource
+		wantRule string
+		wantLeft string
+	}{
+		{"Within day", []domain.Booking{alice("api", at(0, 14), 2*time.Hour)}, nil, "", ""},
+		{"Over day", []domain.Booking{alice("api", at(0, 14), 3*time.Hour)}, nil, "envs.staging.quota.per_day", "2h left"},
+		{"Services together count once", []domain.Booking{alice("api", at(2, 9), 2*time.Hour), alice("web", at(2, 9), 2*time.Hour)}, nil, "", ""},
+		{"Over week", []domain.Booking{alice("api", at(2, 9), 4*time.Hour), alice("api", at(3, 9), 2*time.Hour)}, nil, "envs.staging.quota.per_week", "4h left"},
+		{"Recurring counts every occurrence", []domain.Booking{weekly}, nil, "envs.staging.quota.per_week", "4h left"},
+		{"Too many at once", []domain.Booking{alice("db", at(0, 10), time.Hour)}, nil, "envs.staging.quota.concurrent", "2 already held"},
+		{"Changed booking replaces itself", []domain.Booking{extended}, nil, "envs.staging.quota.per_day", "4h left"},
+		{"Other env has no quota", []domain.Booking{{Env: "qa", Service: "web", StartTime: at(0, 9), Duration: 12 * time.Hour, UserID: "U1"}}, nil, "", ""},
+		{"Team over day", []domain.Booking{alice("api", at(0, 14), 2*time.Hour)}, fakeTeams{"backend": {"U1", "U2"}}, "envs.staging.team_quotas.backend.per_day", "1h left"},
+		{"Not in team", []domain.Booking{alice("api", at(0, 14), time.Hour)}, fakeTeams{"backend": {"U2"}}, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := rules.CheckQuota(ctx, store, tt.bookings, tt.teams)
+			if tt.wantRule == "" {
+				if err != nil {
+					t.Errorf("CheckQuota() error = %v, want nil", err)
+				}
+				return
+			}
+
+			var perr *PolicyError
+			if !errors.As(err, &perr) {
+				t.Fatalf("CheckQuota() error = %v, want a *PolicyError", err)
+			}
+			if perr.Rule != tt.wantRule {
+				t.Errorf("Rule = %s, want %s", perr.Rule, tt.wantRule)
+			}
+			if !strings.Contains(perr.Msg, tt.wantLeft) {
+				t.Errorf("Msg = %q, want it to contain %q", perr.Msg, tt.wantLeft)
+			}
+		})
+	}
+}
+
+func TestQuotaUsages(t *testing.T) {
+	p, err := ParsePolicy([]byte(quotaPolicy))
+	if err != nil {
+		t.Fatalf("ParsePolicy() error = %v", err)
+	}
+	rules := NewRules(p, nil, nil)
+
+	// Tuesday 10:00
+	now := time.Date(2030, 1, 8, 10, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+	store := NewMemoryStore()
+	for _, b := range []domain.Booking{
+		{Env: "staging", Service: "api", StartTime: now.Add(-time.Hour), Duration: 2 * time.Hour, UserID: "U1"},
+		{Env: "staging", Service: "api", StartTime: now.AddDate(0, 0, -1), Duration: 3 * time.Hour, UserID: "U1"},
+		{Env: "staging", Service: "web", StartTime: now, Duration: time.Hour, UserID: "U2"},
+		// Another alice doesn't count towards U1's quota
+		{Env: "staging", Service: "db", StartTime: now, Duration: time.Hour, User: "alice", UserID: "U3"},
+	} {
+		if _, err := store.CreateEvent(ctx, b); err != nil {
+			t.Fatalf("CreateEvent() error = %v", err)
+		}
+	}
+
+	usages, err := rules.QuotaUsages(ctx, store, "U1", "alice", fakeTeams{"backend": {"U1", "U2"}}, now)
+	if err != nil {
+		t.Fatalf("QuotaUsages() error = %v", err)
+	}
+	if len(usages) != 2 {
+		t.Fatalf("QuotaUsages() = %+v, want the user's and the team's", usages)
+	}
+
+	mine, team := usages[0], usages[1]
+	if mine.Team != "" || mine.Day != 2*time.Hour || mine.Week != 5*time.Hour || mine.Concurrent != 1 {
+		t.Errorf("user usage = %+v, want 2h today, 5h this week, 1 at once", mine)
+	}
+	if team.Team != "backend" || team.Day != 3*time.Hour || team.Quota.PerDay != 6*time.Hour {
+		t.Errorf("team usage = %+v, want 3h of backend's 6h today", team)
+	}
+}
@@ -0,0 +1,133 @@
+package calendar
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yossigruner/SlotBot/internal/domain"
+)
+
+// maxOccurrences caps how many times a recurring booking may repeat
+const maxOccurrences = 100
+
+// OccurrenceConflict is an occurrence of a recurring booking that clashes with an existing event.
+type OccurrenceConflict struct {
+	Start time.Time
+	With  domain.Event
+}
+
+// Occurrences returns the start time of every occurrence of the booking,
+// in order. A booking without recurrence has a single occurrence.
+func Occurrences(b domain.Booking) []time.Time {
+	if b.Recurrence == nil {
+		return []time.Time{b.StartTime}
+	}
+
+	step := 1
+	if b.Recurrence.Frequency == domain.FrequencyWeekly {
+		step = 7
+	}
+
+	// Until is a whole day; anything starting before the next midnight counts
+	u := b.Recurrence.Until
+	untilEnd := time.Date(u.Year(), u.Month(), u.Day()+1, 0, 0, 0, 0, b.StartTime.Location())
+
+	var starts []time.Time
+	for day := 0; len(starts) <= maxOccurrences; day += step {
+		// AddDate keeps the wall-clock time across DST changes, like the calendar does
+		t := b.StartTime.AddDate(0, 0, day)
+		if !t.Before(untilEnd) {
+			break
+		}
+		if b.Recurrence.Frequency == domain.FrequencyWeekdays && isWeekend(t) {
+			continue
+		}
+		starts = append(starts, t)
+	}
+
+	return starts
+}
+
+// CheckRecurringConflicts runs CheckConflict for every occurrence of the
+// booking and returns the occurrences that conflict.
+func (r *Rules) CheckRecurringConflicts(b domain.Booking, existingEvents []domain.Event) []OccurrenceConflict {
+	var conflicts []OccurrenceConflict
+	for _, start := range Occurrences(b) {
+		occurrence := b
+		occurrence.StartTime = start
+		if conflict := r.CheckConflict(occurrence, existingEvents); conflict != nil {
+			conflicts = append(conflicts, OccurrenceConflict{Start: start, With: *conflict})
+		}
+	}
+	return conflicts
+}
+
+// RRule returns the RFC 5545 recurrence rule for the booking, or "" if it doesn't repeat.
+func RRule(b domain.Booking) string {
+	if b.Recurrence == nil {
+		return ""
+	}
+
+	occurrences := Occurrences(b)
+	if len(occurrences) == 0 {
+		return ""
+	}
+	until := occurrences[len(occurrences)-1].UTC().Format("20060102T150405Z")
+
+	switch b.Recurrence.Frequency {
+	case domain.FrequencyWeekdays:
+		return "RRULE:FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR;UNTIL=" + until
+	default:
+		return fmt.Sprintf("RRULE:FREQ=%s;UNTIL=%s", strings.ToUpper(b.Recurrence.Frequency), until)
+	}
+}
+
+func validateRecurrence(b domain.Booking) error {
+	switch b.Recurrence.Frequency {
+	case domain.FrequencyDaily, domain.FrequencyWeekdays, domain.FrequencyWeekly:
+	default:
+		return fmt.Errorf("invalid recurrence: %s. Must be daily, weekdays, or weekly", b.Recurrence.Frequency)
+	}
+
+	if b.Recurrence.Frequency == domain.FrequencyWeekdays && isWeekend(b.StartTime) {
+		return fmt.Errorf("weekday bookings must start on a weekday")
+	}
+
+	occurrences := Occurrences(b)
+	if len(occurrences) == 0 {
+		return fmt.Errorf("recurrence end date is before the first booking")
+	}
+
+	if len(occurrences) > maxOccurrences {
+		return fmt.Errorf("a recurring booking can repeat at most %d times", maxOccurrences)
+	}
+
+	return nil
+}
+
+func isWeekend(t time.Time) bool {
+	return t.Weekday() == time.Saturday || t.Weekday() == time.Sunday
+}
+
+// occurrenceEvents expands a booking into the events a store keeps for it.
+// Occurrences of a recurring booking get IDs in the same "<id>_<start>"
+// form Google Calendar uses for recurring event instances.
+func occurrenceEvents(id string, b domain.Booking) []domain.Event {
+	if b.Recurrence == nil {
+		return []domain.Event{bookingEvent(id, b)}
+	}
+
+	var events []domain.Event
+	for _, start := range Occurrences(b) {
+		occurrence := b
+		occurrence.StartTime = start
+		events = append(events, bookingEvent(id+"_"+start.UTC().Format("20060102T150405Z"), occurrence))
+	}
+	return events
+}
+
+// IsOccurrenceOf reports whether id is the series itself or one of its occurrences.
+func IsOccurrenceOf(id, seriesID string) bool {
+	return id == seriesID || strings.HasPrefix(id, seriesID+"_")
+}
@@ -0,0 +1,144 @@
+package calendar
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/yossigruner/SlotBot/internal/domain"
+)
+
+func TestOccurrences(t *testing.T) {
+	// Monday
+	start := time.Date(2030, 1, 7, 2, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		frequency string
+		until     time.Time
+		wantCount int
+		wantLast  time.Time
+		wantRRule string
+	}{
+		{
+			name:      "Daily for a week",
+			frequency: domain.FrequencyDaily,
+			until:     time.Date(2030, 1, 13, 0, 0, 0, 0, time.UTC),
+			wantCount: 7,
+			wantLast:  time.Date(2030, 1, 13, 2, 0, 0, 0, time.UTC),
+			wantRRule: "RRULE:FREQ=DAILY;UNTIL=20300113T020000Z",
+		},
+		{
+			name:      "Weekdays skip the weekend",
+			frequency: domain.FrequencyWeekdays,
+			until:     time.Date(2030, 1, 14, 0, 0, 0, 0, time.UTC),
+			wantCount: 6,
+			wantLast:  time.Date(2030, 1, 14, 2, 0, 0, 0, time.UTC),
+			wantRRule: "RRULE:FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR;UNTIL=20300114T020000Z",
+		},
+		{
+			name:      "Weekly for a month",
+			frequency: domain.FrequencyWeekly,
+			until:     time.Date(2030, 2, 3, 0, 0, 0, 0, time.UTC),
+			wantCount: 4,
+			wantLast:  time.Date(2030, 1, 28, 2, 0, 0, 0, time.UTC),
+			wantRRule: "RRULE:FREQ=WEEKLY;UNTIL=20300128T020000Z",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := domain.Booking{
+				StartTime:  start,
+				Duration:   time.Hour,
+				Recurrence: &domain.Recurrence{Frequency: tt.frequency, Until: tt.until},
+			}
+
+			got := Occurrences(b)
+			if len(got) != tt.wantCount {
+				t.Fatalf("Occurrences() returned %d, want %d", len(got), tt.wantCount)
+			}
+			if !got[len(got)-1].Equal(tt.wantLast) {
+				t.Errorf("Occurrences() last = %v, want %v", got[len(got)-1], tt.wantLast)
+			}
+			if rule := RRule(b); rule != tt.wantRRule {
+				t.Errorf("RRule() = %q, want %q", rule, tt.wantRRule)
+			}
+		})
+	}
+}
+
+func TestCheckRecurringConflicts(t *testing.T) {
+	rules := NewRules(nil, nil, nil)
+	start := time.Date(2030, 1, 7, 2, 0, 0, 0, time.UTC)
+	booking := domain.Booking{
+		Env:        "qa",
+		Service:    "api",
+		StartTime:  start,
+		Duration:   time.Hour,
+		Recurrence: &domain.Recurrence{Frequency: domain.FrequencyDaily, Until: start.AddDate(0, 0, 4)},
+	}
+
+	existingEvents := []domain.Event{
+		{
+			Env:       "qa",
+			Service:   "api",
+			StartTime: start.AddDate(0, 0, 2).Add(30 * time.Minute),
+			EndTime:   start.AddDate(0, 0, 2).Add(90 * time.Minute),
+		},
+		{
+			Env:       "qa",
+			Service:   "web",
+			StartTime: start.AddDate(0, 0, 3),
+			EndTime:   start.AddDate(0, 0, 3).Add(time.Hour),
+		},
+	}
+
+	conflicts := rules.CheckRecurringConflicts(booking, existingEvents)
+	if len(conflicts) != 1 {
+		t.Fatalf("CheckRecurringConflicts() returned %d conflicts, want 1", len(conflicts))
+	}
+	if want := start.AddDate(0, 0, 2); !conflicts[0].Start.Equal(want) {
+		t.Errorf("CheckRecurringConflicts() occurrence = %v, want %v", conflicts[0].Start, want)
+	}
+}
+
+func TestMemoryStoreRecurring(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	start := time.Date(2030, 1, 7, 2, 0, 0, 0, time.UTC)
+
+	created, err := store.CreateEvent(ctx, domain.Booking{
+		Env:        "qa",
+		Service:    "api",
+		StartTime:  start,
+		Duration:   time.Hour,
+		Recurrence: &domain.Recurrence{Frequency: domain.FrequencyWeekly, Until: start.AddDate(0, 0, 21)},
+	})
+	if err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+
+	events, _ := store.ListEvents(ctx, start, start.AddDate(0, 1, 0))
+	if len(events) != 4 {
+		t.Fatalf("ListEvents() returned %d occurrences, want 4", len(events))
+	}
+
+	// Cancelling one occurrence keeps the rest
+	if err := store.DeleteEvent(ctx, events[1].ID); err != nil {
+		t.Fatalf("DeleteEvent() occurrence error = %v", err)
+	}
+	events, _ = store.ListEvents(ctx, start, start.AddDate(0, 1, 0))
+	if len(events) != 3 {
+		t.Fatalf("ListEvents() after deleting an occurrence returned %d, want 3", len(events))
+	}
+
+	// Cancelling the series removes everything
+	if err := store.DeleteEvent(ctx, created.ID); err != nil {
+		t.Fatalf("DeleteEvent() series error = %v", err)
+	}
+	events, _ = store.ListEvents(ctx, start, start.AddDate(0, 1, 0))
+	if len(events) != 0 {
+		t.Errorf("ListEvents() after deleting the series returned %d, want 0", len(events))
+	}
+}
@@ -0,0 +1,36 @@
+package calendar
+
+import (
+	"strings"
+
+	"github.com/yossigruner/SlotBot/internal/domain"
+)
+
+// Rules is what bookings are checked against: the booking policy, each
+// env's working hours and the blackouts read from a calendar. It is built
+// once at startup and shared by everything that books; it is not changed
+// afterwards, so it is safe for concurrent use.
+type Rules struct {
+	policy    *Policy
+	schedules map[string]domain.Schedule // Keyed by lower-cased env name
+	blackouts *BlackoutFeed
+}
+
+// NewRules combines a policy, nil for DefaultPolicy, the working hours,
+// holidays and horizon bookings must fit, keyed by env name with
+// DefaultSchedule for all other envs, and a feed whose blackouts block
+// bookings on top of the policy's, which may be nil.
+func NewRules(policy *Policy, schedules map[string]domain.Schedule, blackouts *BlackoutFeed) *Rules {
+	if policy == nil {
+		policy = DefaultPolicy()
+	}
+	r := &Rules{
+		policy:    policy,
+		schedules: make(map[string]domain.Schedule, len(schedules)),
+		blackouts: blackouts,
+	}
+	for env, schedule := range schedules {
+		r.schedules[strings.ToLower(env)] = schedule
+	}
+	return r
+}
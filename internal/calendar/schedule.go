@@ -0,0 +1,186 @@
+package calendar
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yossigruner/SlotBot/internal/domain"
+)
+
+// ErrNoSlot is returned when nothing is free within the booking horizon
+var ErrNoSlot = errors.New("no free slot within the booking horizon")
+
+// DefaultSchedule is the schedules key for envs without their own entry
+const DefaultSchedule = "*"
+
+// maxSearch bounds the next-slot search for schedules without a horizon
+const maxSearch = 366 * 24 * time.Hour
+
+// ScheduleFor returns the schedule that applies to env
+func (r *Rules) ScheduleFor(env string) domain.Schedule {
+	if s, ok := r.schedules[strings.ToLower(env)]; ok {
+		return s
+	}
+	return r.schedules[DefaultSchedule]
+}
+
+// NextOpen returns the earliest time from t when env is open, or t if
+// nothing opens within a year.
+func (r *Rules) NextOpen(env string, t time.Time) time.Time {
+	if open, ok := nextOpen(r.ScheduleFor(env), t, t.Add(maxSearch)); ok {
+		return open
+	}
+	return t
+}
+
+// checkSchedule reports why b doesn't fit its env's schedule, if it doesn't
+func (r *Rules) checkSchedule(b domain.Booking, now time.Time) error {
+	s := r.ScheduleFor(b.Env)
+
+	// Every occurrence of a recurring booking must be within the horizon
+	occurrences := Occurrences(b)
+	if s.Horizon > 0 && len(occurrences) > 0 && occurrences[len(occurrences)-1].After(now.Add(s.Horizon)) {
+		return fmt.Errorf("%s can be booked at most %s ahead", b.Env, formatHorizon(s.Horizon))
+	}
+
+	for _, start := range occurrences {
+		if !fits(s, start, start.Add(b.Duration)) {
+			return fmt.Errorf("%s is closed at %s: open %s", b.Env, start.In(location(s)).Format("Mon 02 Jan 15:04"), FormatSchedule(s))
+		}
+	}
+	return nil
+}
+
+// FormatSchedule describes the opening hours, e.g. "Mon-Fri 08:00-20:00 Europe/Berlin"
+func FormatSchedule(s domain.Schedule) string {
+	days := "every day"
+	if len(s.Days) > 0 && len(s.Days) < 7 {
+		days = formatDays(s.Days)
+	}
+
+	closeAt := s.Close
+	if closeAt == 0 {
+		closeAt = 24 * time.Hour
+	}
+	hours := fmt.Sprintf("%02d:%02d-%02d:%02d", int(s.Open.Hours()), int(s.Open.Minutes())%60, int(closeAt.Hours()), int(closeAt.Minutes())%60)
+
+	out := days + " " + hours + " " + location(s).String()
+	if len(s.Holidays) > 0 {
+		out += ", closed on holidays"
+	}
+	return out
+}
+
+// formatDays lists open days, collapsing runs like Mon-Fri
+func formatDays(days map[time.Weekday]bool) string {
+	order := []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday}
+
+	var parts []string
+	for i := 0; i < len(order); i++ {
+		if !days[order[i]] {
+			continue
+		}
+		j := i
+		for j+1 < len(order) && days[order[j+1]] {
+			j++
+		}
+		part := order[i].String()[:3]
+		if j > i {
+			part += "-" + order[j].String()[:3]
+		}
+		parts = append(parts, part)
+		i = j
+	}
+	return strings.Join(parts, ",")
+}
+
+func formatHorizon(d time.Duration) string {
+	if days := int(d / (24 * time.Hour)); d%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%d days", days)
+	}
+	return d.String()
+}
+
+func location(s domain.Schedule) *time.Location {
+	if s.Location == nil {
+		return time.UTC
+	}
+	return s.Location
+}
+
+// openUntil returns when the opening that t falls in closes, or false if
+// t is outside opening hours
+func openUntil(s domain.Schedule, t time.Time) (time.Time, bool) {
+	t = t.In(location(s))
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+
+	if len(s.Days) > 0 && !s.Days[t.Weekday()] {
+		return time.Time{}, false
+	}
+	if s.Holidays[day.Format(time.DateOnly)] {
+		return time.Time{}, false
+	}
+
+	closeAt := day.AddDate(0, 0, 1)
+	if s.Close > 0 {
+		closeAt = atTimeOfDay(day, s.Close)
+	}
+	if t.Before(atTimeOfDay(day, s.Open)) || !t.Before(closeAt) {
+		return time.Time{}, false
+	}
+	return closeAt, true
+}
+
+// fits reports whether [start, end) is open the whole time. Openings that
+// run to midnight join up with the next day's if it opens at midnight.
+func fits(s domain.Schedule, start, end time.Time) bool {
+	for t := start; t.Before(end); {
+		closeAt, ok := openUntil(s, t)
+		if !ok {
+			return false
+		}
+		t = closeAt
+	}
+	return true
+}
+
+// closesAt returns when the opening t falls in ends, following openings
+// that join up across midnight, but no later than limit. t must be open.
+func closesAt(s domain.Schedule, t, limit time.Time) time.Time {
+	for t.Before(limit) {
+		closeAt, ok := openUntil(s, t)
+		if !ok {
+			break
+		}
+		t = closeAt
+	}
+	if t.After(limit) {
+		return limit
+	}
+	return t
+}
+
+// nextOpen returns the earliest open time from t that is before limit
+func nextOpen(s domain.Schedule, t, limit time.Time) (time.Time, bool) {
+	if !t.Before(limit) {
+		return time.Time{}, false
+	}
+	if _, ok := openUntil(s, t); ok {
+		return t, true
+	}
+
+	t = t.In(location(s))
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	for !day.After(limit) {
+		open := atTimeOfDay(day, s.Open)
+		if open.After(t) {
+			if _, ok := openUntil(s, open); ok {
+				return open, open.Before(limit)
+			}
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return time.Time{}, false
+}
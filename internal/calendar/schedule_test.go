@@ -0,0 +1,216 @@
+package calendar
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yossigruner/SlotBot/internal/domain"
+)
+
+func TestFindNextSlotWithSchedule(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("LoadLocation() error = %v", err)
+	}
+	rules := NewRules(nil, map[string]domain.Schedule{
+		"staging": {
+			Location: loc,
+			Days:     map[time.Weekday]bool{time.Monday: true, time.Tuesday: true, time.Wednesday: true, time.Thursday: true, time.Friday: true},
+			Open:     8 * time.Hour,
+			Close:    18 * time.Hour,
+			Holidays: map[string]bool{"2030-01-14": true},
+			Horizon:  14 * 24 * time.Hour,
+		},
+	}, nil)
+
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2030, 1, day, hour, min, 0, 0, loc)
+	}
+	// Friday 11 Jan, busy until 17:00
+	events := []domain.Event{
+		{Env: "staging", Service: "api", StartTime: at(11, 9, 0), EndTime: at(11, 17, 0)},
+	}
+
+	tests := []struct {
+		name     string
+		env      string
+		now      time.Time
+		duration time.Duration
+		want     time.Time
+		wantErr  error
+	}{
+		{name: "Open and free", env: "staging", now: at(10, 9, 0), duration: time.Hour, want: at(10, 9, 0)},
+		{name: "Before opening", env: "staging", now: at(10, 3, 0), duration: time.Hour, want: at(10, 8, 0)},
+		{name: "Fits before a booking", env: "staging", now: at(11, 8, 0), duration: time.Hour, want: at(11, 8, 0)},
+		{name: "Gap before closing", env: "staging", now: at(11, 8, 30), duration: time.Hour, want: at(11, 17, 0)},
+		// Too long for the last hour on Friday; the weekend and Monday's holiday are skipped
+		{name: "Skips weekend and holiday", env: "staging", now: at(11, 8, 30), duration: 2 * time.Hour, want: at(15, 8, 0)},
+		{name: "Longer than opening hours", env: "staging", now: at(10, 9, 0), duration: 11 * time.Hour, wantErr: ErrNoSlot},
+		{name: "Env without schedule", env: "qa", now: at(12, 3, 0), duration: time.Hour, want: at(12, 3, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rules.findNextSlot(tt.env, []string{"api"}, tt.duration, events, tt.now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("findNextSlot() error = %v, want %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("findNextSlot() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindNextSlotBeyondHorizon(t *testing.T) {
+	rules := NewRules(nil, map[string]domain.Schedule{
+		DefaultSchedule: {Horizon: 24 * time.Hour},
+	}, nil)
+
+	now := time.Date(2030, 1, 9, 10, 0, 0, 0, time.UTC)
+	events := []domain.Event{
+		{Env: "qa", Service: "web", StartTime: now, EndTime: now.Add(48 * time.Hour)},
+	}
+
+	if got, err := rules.findNextSlot("qa", []string{"web"}, time.Hour, events, now); !errors.Is(err, ErrNoSlot) {
+		t.Errorf("findNextSlot() = %v, %v, want ErrNoSlot", got, err)
+	}
+}
+
+func TestCheckSchedule(t *testing.T) {
+	rules := NewRules(nil, map[string]domain.Schedule{
+		DefaultSchedule: {
+			Days:    map[time.Weekday]bool{time.Monday: true, time.Tuesday: true, time.Wednesday: true, time.Thursday: true, time.Friday: true},
+			Open:    8 * time.Hour,
+			Horizon: 7 * 24 * time.Hour,
+		},
+	}, nil)
+
+	// Wednesday
+	now := time.Date(2030, 1, 9, 10, 0, 0, 0, time.UTC)
+	at := func(day, hour int) time.Time {
+		return time.Date(2030, 1, day, hour, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name    string
+		booking domain.Booking
+		wantErr bool
+	}{
+		{name: "Working hours", booking: domain.Booking{Env: "qa", StartTime: at(9, 14), Duration: time.Hour}},
+		{name: "Open until midnight runs into the next day", booking: domain.Booking{Env: "qa", StartTime: at(9, 23), Duration: 2 * time.Hour}, wantErr: true},
+		{name: "Before opening", booking: domain.Booking{Env: "qa", StartTime: at(10, 7), Duration: time.Hour}, wantErr: true},
+		{name: "Weekend", booking: domain.Booking{Env: "qa", StartTime: at(12, 10), Duration: time.Hour}, wantErr: true},
+		{name: "Beyond horizon", booking: domain.Booking{Env: "qa", StartTime: at(21, 10), Duration: time.Hour}, wantErr: true},
+		{
+			name: "Recurring into the weekend",
+			booking: domain.Booking{
+				Env: "qa", StartTime: at(10, 10), Duration: time.Hour,
+				Recurrence: &domain.Recurrence{Frequency: domain.FrequencyDaily, Until: at(13, 0)},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Recurring past the horizon",
+			booking: domain.Booking{
+				Env: "qa", StartTime: at(10, 10), Duration: time.Hour,
+				Recurrence: &domain.Recurrence{Frequency: domain.FrequencyWeekdays, Until: at(25, 0)},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Recurring on weekdays",
+			booking: domain.Booking{
+				Env: "qa", StartTime: at(10, 10), Duration: time.Hour,
+				Recurrence: &domain.Recurrence{Frequency: domain.FrequencyWeekdays, Until: at(15, 0)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := rules.checkSchedule(tt.booking, now); (err != nil) != tt.wantErr {
+				t.Errorf("checkSchedule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestScheduleAcrossDST(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("LoadLocation() error = %v", err)
+	}
+	rules := NewRules(nil, map[string]domain.Schedule{
+		DefaultSchedule: {Open: 8 * time.Hour, Close: 20 * time.Hour, Location: loc},
+	}, nil)
+
+	// Clocks go forward at 02:00 on Sunday 2030-03-31 and back at 03:00 on 2030-10-27
+	for _, day := range []time.Time{time.Date(2030, 3, 31, 0, 0, 0, 0, loc), time.Date(2030, 10, 27, 0, 0, 0, 0, loc)} {
+		open := time.Date(day.Year(), day.Month(), day.Day(), 8, 0, 0, 0, loc)
+		closeAt := time.Date(day.Year(), day.Month(), day.Day(), 20, 0, 0, 0, loc)
+
+		if got := rules.NextOpen("qa", open.Add(-3*time.Hour)); !got.Equal(open) {
+			t.Errorf("NextOpen() on %s = %v, want %v", open.Format(time.DateOnly), got.In(loc), open)
+		}
+		if !fits(rules.ScheduleFor("qa"), open, closeAt) {
+			t.Errorf("fits(%v, %v) = false, want open all day", open, closeAt)
+		}
+		if fits(rules.ScheduleFor("qa"), closeAt.Add(-time.Hour), closeAt.Add(time.Minute)) {
+			t.Errorf("fits() past %v = true, want closed", closeAt)
+		}
+	}
+}
+
+func TestFormatSchedule(t *testing.T) {
+	s := domain.Schedule{
+		Days:  map[time.Weekday]bool{time.Monday: true, time.Tuesday: true, time.Wednesday: true, time.Friday: true},
+		Open:  8*time.Hour + 30*time.Minute,
+		Close: 18 * time.Hour,
+	}
+	if got, want := FormatSchedule(s), "Mon-Wed,Fri 08:30-18:00 UTC"; got != want {
+		t.Errorf("FormatSchedule() = %q, want %q", got, want)
+	}
+}
+
+func TestFreeSlots(t *testing.T) {
+	rules := NewRules(nil, map[string]domain.Schedule{
+		"staging": {Open: 8 * time.Hour, Close: 18 * time.Hour},
+	}, nil)
+
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2030, 1, day, hour, min, 0, 0, time.UTC)
+	}
+	events := []domain.Event{
+		{Env: "staging", Service: "api", StartTime: at(9, 10, 0), EndTime: at(9, 11, 0)},
+		{Env: "staging", Service: "api", StartTime: at(9, 11, 30), EndTime: at(9, 16, 0)},
+		{Env: "staging", Service: "web", StartTime: at(9, 16, 0), EndTime: at(9, 17, 0)},
+	}
+
+	got, err := rules.freeSlots("staging", []string{"api"}, time.Hour, events, at(9, 8, 30), at(9, 8, 30), 3)
+	if err != nil {
+		t.Fatalf("freeSlots() error = %v", err)
+	}
+
+	// The half hour between bookings is too short; the web booking doesn't matter
+	want := []Slot{
+		{Start: at(9, 8, 30), End: at(9, 10, 0)},
+		{Start: at(9, 16, 0), End: at(9, 18, 0)},
+		{Start: at(10, 8, 0), End: at(10, 18, 0)},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("freeSlots() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if !got[i].Start.Equal(want[i].Start) || !got[i].End.Equal(want[i].End) {
+			t.Errorf("freeSlots()[%d] = %v - %v, want %v - %v", i, got[i].Start, got[i].End, want[i].Start, want[i].End)
+		}
+	}
+
+	// Both services must be free
+	got, err = rules.freeSlots("staging", []string{"api", "web"}, time.Hour, events, at(9, 12, 0), at(9, 8, 30), 1)
+	if err != nil || !got[0].Start.Equal(at(9, 17, 0)) {
+		t.Errorf("freeSlots(api, web) = %v, %v, want a slot at 17:00", got, err)
+	}
+}
@@ -0,0 +1,191 @@
+package calendar
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yossigruner/SlotBot/internal/domain"
+	_ "modernc.org/sqlite" // Registers the "sqlite" database/sql driver
+)
+
+// sqliteMigrations are applied in order; PRAGMA user_version records how many have run.
+var sqliteMigrations = []string{
+	`CREATE TABLE bookings (
+		id          TEXT PRIMARY KEY,
+		env         TEXT NOT NULL,
+		service     TEXT NOT NULL,
+		jira_ticket TEXT NOT NULL,
+		user_name   TEXT NOT NULL,
+		start_time  INTEGER NOT NULL,
+		end_time    INTEGER NOT NULL
+	)`,
+	`CREATE INDEX bookings_time ON bookings (start_time, end_time)`,
+	`ALTER TABLE bookings ADD COLUMN user_id TEXT NOT NULL DEFAULT ''`,
+}
+
+// SQLiteStore is a BookingStore backed by a local SQLite database file.
+// It needs no external service.
+type SQLiteStore struct {
+	db       *sql.DB
+	timezone *time.Location
+}
+
+func NewSQLiteStore(path string, timezone *time.Location) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open sqlite database: %w", err)
+	}
+	// SQLite allows a single writer; serialise access through one connection.
+	db.SetMaxOpenConns(1)
+
+	if err := migrateSQLite(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLiteStore{
+		db:       db,
+		timezone: timezone,
+	}, nil
+}
+
+func migrateSQLite(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return fmt.Errorf("unable to read sqlite schema version: %w", err)
+	}
+
+	for i := version; i < len(sqliteMigrations); i++ {
+		if _, err := db.Exec(sqliteMigrations[i]); err != nil {
+			return fmt.Errorf("sqlite migration %d failed: %w", i+1, err)
+		}
+		// PRAGMA does not accept bind parameters
+		if _, err := db.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, i+1)); err != nil {
+			return fmt.Errorf("unable to record sqlite schema version: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *SQLiteStore) ListEvents(ctx context.Context, start, end time.Time) ([]domain.Event, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, env, service, jira_ticket, user_name, user_id, start_time, end_time
+		FROM bookings
+		WHERE start_time < ? AND end_time > ?
+		ORDER BY start_time`,
+		end.Unix(), start.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("unable to list bookings: %w", err)
+	}
+	defer rows.Close()
+
+	var events []domain.Event
+	for rows.Next() {
+		var (
+			id                 string
+			booking            domain.Booking
+			startUnix, endUnix int64
+		)
+		if err := rows.Scan(&id, &booking.Env, &booking.Service, &booking.JiraTicket, &booking.User, &booking.UserID, &startUnix, &endUnix); err != nil {
+			return nil, fmt.Errorf("unable to read booking: %w", err)
+		}
+		booking.StartTime = time.Unix(startUnix, 0).In(s.timezone)
+		booking.Duration = time.Duration(endUnix-startUnix) * time.Second
+
+		events = append(events, bookingEvent(id, booking))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("unable to list bookings: %w", err)
+	}
+
+	return events, nil
+}
+
+func (s *SQLiteStore) CreateEvent(ctx context.Context, booking domain.Booking) (*domain.Event, error) {
+	id := newBookingID()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create booking: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, event := range occurrenceEvents(id, booking) {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO bookings (id, env, service, jira_ticket, user_name, user_id, start_time, end_time)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			event.ID,
+			event.Env,
+			event.Service,
+			event.JiraTicket,
+			event.User,
+			event.UserID,
+			event.StartTime.Unix(),
+			event.EndTime.Unix())
+		if err != nil {
+			return nil, fmt.Errorf("unable to create booking: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("unable to create booking: %w", err)
+	}
+
+	event := bookingEvent(id, booking)
+	return &event, nil
+}
+
+func (s *SQLiteStore) UpdateEvent(ctx context.Context, id string, booking domain.Booking) (*domain.Event, error) {
+	res, err := s.db.ExecContext(ctx,
+		`UPDATE bookings
+		SET env = ?, service = ?, jira_ticket = ?, user_name = ?, user_id = ?, start_time = ?, end_time = ?
+		WHERE id = ?`,
+		strings.ToLower(booking.Env),
+		strings.ToLower(booking.Service),
+		strings.ToUpper(booking.JiraTicket),
+		booking.User,
+		booking.UserID,
+		booking.StartTime.Unix(),
+		booking.StartTime.Add(booking.Duration).Unix(),
+		id)
+	if err != nil {
+		return nil, fmt.Errorf("unable to update booking: %w", err)
+	}
+	if err := expectOneRow(res); err != nil {
+		return nil, err
+	}
+
+	event := bookingEvent(id, booking)
+	return &event, nil
+}
+
+func (s *SQLiteStore) DeleteEvent(ctx context.Context, id string) error {
+	// Deleting a recurring booking's ID removes every occurrence
+	res, err := s.db.ExecContext(ctx,
+		`DELETE FROM bookings WHERE id = ? OR substr(id, 1, ?) = ?`,
+		id, len(id)+1, id+"_")
+	if err != nil {
+		return fmt.Errorf("unable to delete booking: %w", err)
+	}
+	return expectOneRow(res)
+}
+
+// expectOneRow maps an UPDATE or DELETE that touched no rows to ErrNotFound.
+func expectOneRow(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("unable to check affected rows: %w", err)
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
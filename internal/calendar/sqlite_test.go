@@ -0,0 +1,78 @@
+package calendar
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yossigruner/SlotBot/internal/domain"
+)
+
+func TestSQLiteStore(t *testing.T) {
+	rules := NewRules(nil, nil, nil)
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "slotbot.db")
+	start := time.Date(2030, 1, 7, 10, 0, 0, 0, time.UTC)
+
+	store, err := NewSQLiteStore(path, time.UTC)
+	if err != nil {
+		t.Fatalf("NewSQLiteStore() error = %v", err)
+	}
+
+	booking := domain.Booking{
+		Env:        "staging",
+		Service:    "api",
+		JiraTicket: "proj-1",
+		StartTime:  start,
+		Duration:   time.Hour,
+		User:       "alice",
+	}
+	created, err := store.CreateEvent(ctx, booking)
+	if err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	store.Close()
+
+	// Reopen to check bookings survive a restart
+	store, err = NewSQLiteStore(path, time.UTC)
+	if err != nil {
+		t.Fatalf("NewSQLiteStore() reopen error = %v", err)
+	}
+	defer store.Close()
+
+	events, err := store.ListEvents(ctx, start.Add(30*time.Minute), start.Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("ListEvents() error = %v", err)
+	}
+	if len(events) != 1 || events[0].ID != created.ID {
+		t.Fatalf("ListEvents() = %v, want the created booking", events)
+	}
+	if !events[0].StartTime.Equal(start) || !events[0].EndTime.Equal(start.Add(time.Hour)) {
+		t.Errorf("ListEvents() times = %v - %v, want %v - %v", events[0].StartTime, events[0].EndTime, start, start.Add(time.Hour))
+	}
+
+	if conflict := rules.CheckConflict(booking, events); conflict == nil {
+		t.Errorf("CheckConflict() = nil, want conflict with stored booking")
+	}
+
+	booking.Duration = 2 * time.Hour
+	if _, err := store.UpdateEvent(ctx, created.ID, booking); err != nil {
+		t.Fatalf("UpdateEvent() error = %v", err)
+	}
+	events, _ = store.ListEvents(ctx, start.Add(90*time.Minute), start.Add(3*time.Hour))
+	if len(events) != 1 {
+		t.Errorf("ListEvents() after update returned %d events, want 1", len(events))
+	}
+
+	if err := store.DeleteEvent(ctx, created.ID); err != nil {
+		t.Fatalf("DeleteEvent() error = %v", err)
+	}
+	if err := store.DeleteEvent(ctx, created.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("DeleteEvent() twice error = %v, want ErrNotFound", err)
+	}
+	if _, err := store.UpdateEvent(ctx, created.ID, booking); !errors.Is(err, ErrNotFound) {
+		t.Errorf("UpdateEvent() on deleted booking error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,84 @@
+package calendar
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yossigruner/SlotBot/internal/config"
+	"github.com/yossigruner/SlotBot/internal/domain"
+)
+
+// ErrNotFound is returned by a BookingStore when the requested event does not exist.
+var ErrNotFound = errors.New("booking not found")
+
+// BookingStore is the persistence layer for bookings. The Google Calendar
+// Client is the production implementation; MemoryStore is meant for local
+// runs and tests.
+type BookingStore interface {
+	// ListEvents returns the events overlapping [start, end), sorted by start time.
+	ListEvents(ctx context.Context, start, end time.Time) ([]domain.Event, error)
+	CreateEvent(ctx context.Context, booking domain.Booking) (*domain.Event, error)
+	UpdateEvent(ctx context.Context, id string, booking domain.Booking) (*domain.Event, error)
+	DeleteEvent(ctx context.Context, id string) error
+}
+
+// newBookingID returns a short random identifier for a booking. It only uses
+// base32hex characters so it is also a valid Google Calendar event ID.
+func newBookingID() string {
+	const alphabet = "0123456789abcdefghijklmnopqrstuv"
+
+	b := make([]byte, 10)
+	rand.Read(b)
+	for i := range b {
+		b[i] = alphabet[b[i]%byte(len(alphabet))]
+	}
+	return string(b)
+}
+
+// bookingEvent builds the Event a store returns for a booking it holds.
+func bookingEvent(id string, booking domain.Booking) domain.Event {
+	return domain.Event{
+		ID:        id,
+		Title:     eventSummary(booking),
+		StartTime: booking.StartTime,
+		EndTime:   booking.StartTime.Add(booking.Duration),
+		Env:       strings.ToLower(booking.Env),
+		Service:   strings.ToLower(booking.Service),
+
+		JiraTicket: strings.ToUpper(booking.JiraTicket),
+		User:       booking.User,
+		UserID:     booking.UserID,
+	}
+}
+
+// eventSummary builds the event title: env | service | jira | holder
+func eventSummary(booking domain.Booking) string {
+	return fmt.Sprintf("%s | %s | %s | %s",
+		strings.ToLower(booking.Env),
+		strings.ToLower(booking.Service),
+		strings.ToUpper(booking.JiraTicket),
+		booking.User,
+	)
+}
+
+var (
+	_ BookingStore = (*Client)(nil)
+	_ BookingStore = (*MemoryStore)(nil)
+	_ BookingStore = (*SQLiteStore)(nil)
+)
+
+// NewStore creates the BookingStore selected by cfg.BookingStore.
+func NewStore(ctx context.Context, cfg *config.Config) (BookingStore, error) {
+	switch cfg.BookingStore {
+	case config.StoreSQLite:
+		return NewSQLiteStore(cfg.SQLitePath, cfg.DefaultTimezone)
+	case config.StoreMemory:
+		return NewMemoryStore(), nil
+	default:
+		return NewClient(ctx, cfg)
+	}
+}
@@ -3,7 +3,18 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/yossigruner/SlotBot/internal/domain"
+)
+
+// Supported values for BOOKING_STORE
+const (
+	StoreGoogle = "google"
+	StoreSQLite = "sqlite"
+	StoreMemory = "memory"
 )
 
 type Config struct {
@@ -12,6 +23,18 @@ type Config struct {
 	GoogleCalendarID   string
 	DefaultTimezone    *time.Location
 	Port               string
+	BookingStore       string // One of StoreGoogle, StoreSQLite, StoreMemory
+	SQLitePath         string
+	MineHorizon        time.Duration // How far ahead /slot mine looks
+	WaitlistPath       string        // JSON file the waitlist is saved to
+	BookASAP           bool          // Move conflicting bookings to the next free slot by default
+	APIToken           string        // Bearer token for /api; the API is off when empty
+	PolicyPath         string        // Booking policy file; empty means the built-in policy
+	AuditLogPath       string        // JSON Lines file urgent override decisions are appended to
+	BlackoutCalendarID string        // Google Calendar whose events black out envs; empty for none
+	// Working hours, holidays and horizon per env, keyed by env name or "*"
+	// for every other env. Always has a "*" entry.
+	Schedules map[string]domain.Schedule
 }
 
 func Load() (*Config, error) {
@@ -29,11 +52,205 @@ func Load() (*Config, error) {
 		port = "8080"
 	}
 
+	store := strings.ToLower(os.Getenv("BOOKING_STORE"))
+	if store == "" {
+		store = StoreGoogle
+	}
+	switch store {
+	case StoreGoogle, StoreSQLite, StoreMemory:
+	default:
+		return nil, fmt.Errorf("invalid BOOKING_STORE %q: must be %s, %s or %s", store, StoreGoogle, StoreSQLite, StoreMemory)
+	}
+
+	sqlitePath := os.Getenv("SQLITE_PATH")
+	if sqlitePath == "" {
+		sqlitePath = "slotbot.db"
+	}
+
+	mineHorizonDays := 14
+	if v := os.Getenv("MINE_HORIZON_DAYS"); v != "" {
+		mineHorizonDays, err = strconv.Atoi(v)
+		if err != nil || mineHorizonDays <= 0 {
+			return nil, fmt.Errorf("invalid MINE_HORIZON_DAYS %q: must be a positive number of days", v)
+		}
+	}
+
+	waitlistPath := os.Getenv("WAITLIST_PATH")
+	if waitlistPath == "" {
+		waitlistPath = "waitlist.json"
+	}
+
+	auditLogPath := os.Getenv("AUDIT_LOG_PATH")
+	if auditLogPath == "" {
+		auditLogPath = "audit.jsonl"
+	}
+
+	bookASAP := false
+	if v := os.Getenv("BOOK_ASAP"); v != "" {
+		bookASAP, err = strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BOOK_ASAP %q: must be true or false", v)
+		}
+	}
+
+	schedules, err := loadSchedules(loc)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Config{
 		SlackSigningSecret: os.Getenv("SLACK_SIGNING_SECRET"),
 		SlackBotToken:      os.Getenv("SLACK_BOT_TOKEN"),
 		GoogleCalendarID:   os.Getenv("GCAL_CALENDAR_ID"),
 		DefaultTimezone:    loc,
 		Port:               port,
+		BookingStore:       store,
+		SQLitePath:         sqlitePath,
+		MineHorizon:        time.Duration(mineHorizonDays) * 24 * time.Hour,
+		WaitlistPath:       waitlistPath,
+		BookASAP:           bookASAP,
+		APIToken:           os.Getenv("API_TOKEN"),
+		PolicyPath:         os.Getenv("POLICY_PATH"),
+		AuditLogPath:       auditLogPath,
+		BlackoutCalendarID: os.Getenv("BLACKOUT_CALENDAR_ID"),
+		Schedules:          schedules,
 	}, nil
 }
+
+// loadSchedules reads WORKING_HOURS, HOLIDAYS and BOOKING_HORIZON_DAYS.
+// WORKING_HOURS is a ';'-separated list of "<env>=<days> <HH:MM-HH:MM> [timezone]",
+// e.g. "staging=mon-fri 08:00-20:00 Europe/Berlin;*=daily 07:00-23:00". Envs
+// without an entry, and entries without a timezone, use DEFAULT_TIMEZONE.
+func loadSchedules(defaultLoc *time.Location) (map[string]domain.Schedule, error) {
+	horizonDays := 30
+	if v := os.Getenv("BOOKING_HORIZON_DAYS"); v != "" {
+		var err error
+		horizonDays, err = strconv.Atoi(v)
+		if err != nil || horizonDays < 0 {
+			return nil, fmt.Errorf("invalid BOOKING_HORIZON_DAYS %q: must be a number of days, 0 for no limit", v)
+		}
+	}
+
+	holidays := map[string]bool{}
+	for _, d := range strings.Split(os.Getenv("HOLIDAYS"), ",") {
+		d = strings.TrimSpace(d)
+		if d == "" {
+			continue
+		}
+		if _, err := time.Parse(time.DateOnly, d); err != nil {
+			return nil, fmt.Errorf("invalid HOLIDAYS date %q: use YYYY-MM-DD", d)
+		}
+		holidays[d] = true
+	}
+
+	schedules := map[string]domain.Schedule{"*": {Location: defaultLoc}}
+	for _, entry := range strings.Split(os.Getenv("WORKING_HOURS"), ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		env, spec, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid WORKING_HOURS entry %q: want <env>=<days> <HH:MM-HH:MM> [timezone]", entry)
+		}
+		schedule, err := parseSchedule(spec, defaultLoc)
+		if err != nil {
+			return nil, fmt.Errorf("invalid WORKING_HOURS for %s: %w", strings.TrimSpace(env), err)
+		}
+		schedules[strings.ToLower(strings.TrimSpace(env))] = schedule
+	}
+
+	for env, schedule := range schedules {
+		schedule.Holidays = holidays
+		schedule.Horizon = time.Duration(horizonDays) * 24 * time.Hour
+		schedules[env] = schedule
+	}
+	return schedules, nil
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// parseSchedule parses "<days> <HH:MM-HH:MM> [timezone]"
+func parseSchedule(spec string, defaultLoc *time.Location) (domain.Schedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) < 2 || len(fields) > 3 {
+		return domain.Schedule{}, fmt.Errorf("%q: want <days> <HH:MM-HH:MM> [timezone]", spec)
+	}
+
+	schedule := domain.Schedule{Location: defaultLoc}
+	if len(fields) == 3 {
+		loc, err := time.LoadLocation(fields[2])
+		if err != nil {
+			return domain.Schedule{}, fmt.Errorf("invalid timezone: %w", err)
+		}
+		schedule.Location = loc
+	}
+
+	days, err := ParseWeekdays(strings.ToLower(fields[0]))
+	if err != nil {
+		return domain.Schedule{}, err
+	}
+	schedule.Days = days
+
+	openStr, closeStr, ok := strings.Cut(fields[1], "-")
+	if !ok {
+		return domain.Schedule{}, fmt.Errorf("invalid hours %q: want HH:MM-HH:MM", fields[1])
+	}
+	if schedule.Open, err = ParseTimeOfDay(openStr); err != nil {
+		return domain.Schedule{}, err
+	}
+	if schedule.Close, err = ParseTimeOfDay(closeStr); err != nil {
+		return domain.Schedule{}, err
+	}
+	if schedule.Close <= schedule.Open {
+		return domain.Schedule{}, fmt.Errorf("invalid hours %q: closing time must be after opening time", fields[1])
+	}
+	if schedule.Close == 24*time.Hour {
+		schedule.Close = 0
+	}
+
+	return schedule, nil
+}
+
+// ParseWeekdays parses "daily", or comma-separated days and ranges like "mon-fri,sun"
+func ParseWeekdays(spec string) (map[time.Weekday]bool, error) {
+	if spec == "daily" || spec == "*" {
+		return nil, nil
+	}
+
+	days := map[time.Weekday]bool{}
+	for _, part := range strings.Split(spec, ",") {
+		from, to, isRange := strings.Cut(part, "-")
+		first, ok := weekdayNames[from]
+		if !ok {
+			return nil, fmt.Errorf("invalid day %q: use mon, tue, ... or daily", from)
+		}
+		last := first
+		if isRange {
+			if last, ok = weekdayNames[to]; !ok {
+				return nil, fmt.Errorf("invalid day %q: use mon, tue, ... or daily", to)
+			}
+		}
+		for d := first; ; d = (d + 1) % 7 {
+			days[d] = true
+			if d == last {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+// ParseTimeOfDay parses HH:MM, allowing 24:00 for midnight at the end of the day
+func ParseTimeOfDay(s string) (time.Duration, error) {
+	if s == "24:00" {
+		return 24 * time.Hour, nil
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q: use HH:MM", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
@@ -6,19 +6,81 @@ import (
 
 // Booking represents a parsed booking request
 type Booking struct {
+	ID         string // Set when changing an existing booking
 	Env        string
 	Service    string
 	JiraTicket string
 	StartTime  time.Time
 	Duration   time.Duration
-	User       string // Slack user ID or Name
+	User       string      // Slack user name, shown in the event title
+	UserID     string      // Slack user ID of the person who booked
+	Recurrence *Recurrence // Optional, repeats the booking
+}
+
+// AllServices is the reserved service name for a whole-environment lock. It
+// conflicts with every service booking in the same environment.
+const AllServices = "*"
+
+// Supported recurrence frequencies
+const (
+	FrequencyDaily    = "daily"
+	FrequencyWeekdays = "weekdays"
+	FrequencyWeekly   = "weekly"
+)
+
+// Recurrence repeats a booking at the same time of day until a date
+type Recurrence struct {
+	Frequency string    // One of FrequencyDaily, FrequencyWeekdays, FrequencyWeekly
+	Until     time.Time // Last day an occurrence may start on (inclusive)
+}
+
+// Schedule is when an environment can be booked. The zero value allows any
+// time, with no limit on how far ahead.
+type Schedule struct {
+	Location *time.Location        // Timezone the hours and holidays are in; nil means UTC
+	Days     map[time.Weekday]bool // Open days; empty means every day
+	Open     time.Duration         // Opening time of day
+	Close    time.Duration         // Closing time of day; 0 means midnight at the end of the day
+	Holidays map[string]bool       // Closed dates as YYYY-MM-DD
+	Horizon  time.Duration         // How far ahead bookings may start; 0 means no limit
+}
+
+// Blackout is a window when an environment can't be booked at all, like
+// maintenance or a deploy freeze
+type Blackout struct {
+	Envs   []string // Affected environments; empty means every environment
+	Start  time.Time
+	End    time.Time
+	Reason string
 }
 
 // Event represents a calendar event for conflict checking
 type Event struct {
+	ID        string // Store-specific identifier, used for updates and deletes
 	Title     string
 	StartTime time.Time
 	EndTime   time.Time
 	Env       string
 	Service   string
+	Link      string // Optional link to the event in the backing calendar
+	Blackout  bool   // A blackout window locking the whole env, not a booking; Title has the reason
+
+	// Booking details, empty for events not created by the bot
+	JiraTicket string
+	User       string
+	UserID     string
+}
+
+// Booking returns the booking the event represents, so it can be changed and saved again.
+func (e Event) Booking() Booking {
+	return Booking{
+		ID:         e.ID,
+		Env:        e.Env,
+		Service:    e.Service,
+		JiraTicket: e.JiraTicket,
+		StartTime:  e.StartTime,
+		Duration:   e.EndTime.Sub(e.StartTime),
+		User:       e.User,
+		UserID:     e.UserID,
+	}
 }
@@ -0,0 +1,204 @@
+// Package grid draws an environment's day as a text timeline, one row per
+// service and one column per 15-minute slot. It is shared by /slot grid and
+// the HTTP API.
+package grid
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yossigruner/SlotBot/internal/domain"
+)
+
+// SlotSize is the width of one column, the same grid bookings are rounded to
+const SlotSize = 15 * time.Minute
+
+// Cell markers
+const (
+	Busy = '#'
+	Free = '.'
+)
+
+// The window drawn when no booking falls outside it
+const (
+	defaultFirstHour = 8
+	defaultLastHour  = 20
+)
+
+// Grid is one environment's bookings for a day on the slot grid
+type Grid struct {
+	Env      string
+	Start    time.Time // Start of the first column
+	Slots    int
+	Services []string
+	busy     map[string][]bool
+}
+
+// New lays out env's events for the day containing day. The window covers
+// 08:00-20:00, widened to whole hours to include any booking outside it.
+// Whole-environment locks mark every service busy.
+func New(env string, day time.Time, events []domain.Event) *Grid {
+	dayStart := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	dayEnd := dayStart.AddDate(0, 0, 1)
+
+	start := dayStart.Add(defaultFirstHour * time.Hour)
+	end := dayStart.Add(defaultLastHour * time.Hour)
+
+	var envEvents []domain.Event
+	seen := map[string]bool{}
+	var services []string
+	for _, e := range events {
+		if !strings.EqualFold(e.Env, env) || !e.EndTime.After(dayStart) || !e.StartTime.Before(dayEnd) {
+			continue
+		}
+		envEvents = append(envEvents, e)
+
+		if e.StartTime.Before(start) {
+			start = maxTime(floorHour(e.StartTime.In(day.Location())), dayStart)
+		}
+		if e.EndTime.After(end) {
+			end = minTime(ceilHour(e.EndTime.In(day.Location())), dayEnd)
+		}
+
+		if e.Service != domain.AllServices && !seen[e.Service] {
+			seen[e.Service] = true
+			services = append(services, e.Service)
+		}
+	}
+	sort.Strings(services)
+	if len(services) == 0 && len(envEvents) > 0 {
+		services = []string{domain.AllServices}
+	}
+
+	g := &Grid{
+		Env:      env,
+		Start:    start,
+		Slots:    int(end.Sub(start) / SlotSize),
+		Services: services,
+		busy:     make(map[string][]bool, len(services)),
+	}
+	for _, s := range services {
+		g.busy[s] = make([]bool, g.Slots)
+	}
+
+	for _, e := range envEvents {
+		rows := []string{e.Service}
+		if e.Service == domain.AllServices {
+			rows = services
+		}
+		for i := 0; i < g.Slots; i++ {
+			slotStart := g.Start.Add(time.Duration(i) * SlotSize)
+			if e.StartTime.Before(slotStart.Add(SlotSize)) && e.EndTime.After(slotStart) {
+				for _, s := range rows {
+					g.busy[s][i] = true
+				}
+			}
+		}
+	}
+
+	return g
+}
+
+// IsBusy reports whether service is booked during slot i
+func (g *Grid) IsBusy(service string, i int) bool {
+	return g.busy[service][i]
+}
+
+// String renders the grid with an hour scale above the rows and a legend
+// below, e.g.
+//
+//	     08  09  10  11
+//	api  ..##########..
+//	web  ........####..
+func (g *Grid) String() string {
+	var sb strings.Builder
+
+	end := g.Start.Add(time.Duration(g.Slots) * SlotSize)
+	sb.WriteString(fmt.Sprintf("%s  %s %s-%s\n", g.Env, g.Start.Format("Mon 02 Jan"), g.Start.Format("15:04"), end.Format("15:04")))
+
+	if len(g.Services) == 0 {
+		sb.WriteString("No bookings - free all day\n")
+		return sb.String()
+	}
+
+	labelWidth := 0
+	for _, s := range g.Services {
+		if len(displayService(s)) > labelWidth {
+			labelWidth = len(displayService(s))
+		}
+	}
+
+	// Hour scale: a label every four columns, starting on the hour
+	slotsPerHour := int(time.Hour / SlotSize)
+	sb.WriteString(strings.Repeat(" ", labelWidth+2))
+	for i := 0; i < g.Slots; i += slotsPerHour {
+		sb.WriteString(fmt.Sprintf("%-*s", min(slotsPerHour, g.Slots-i), g.Start.Add(time.Duration(i)*SlotSize).Format("15")))
+	}
+	sb.WriteString("\n")
+
+	for _, s := range g.Services {
+		sb.WriteString(fmt.Sprintf("%-*s  ", labelWidth, displayService(s)))
+		for i := 0; i < g.Slots; i++ {
+			if g.busy[s][i] {
+				sb.WriteRune(Busy)
+			} else {
+				sb.WriteRune(Free)
+			}
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString(fmt.Sprintf("%c busy  %c free  (one column = %d min)\n", Busy, Free, int(SlotSize/time.Minute)))
+	return sb.String()
+}
+
+func displayService(service string) string {
+	if service == domain.AllServices {
+		return "* (all)"
+	}
+	return service
+}
+
+// Round rounds t to the nearest slot boundary, e.g. 10:07 to 10:00 and
+// 10:08 to 10:15
+func Round(t time.Time) time.Time {
+	minutes := (t.Minute() + 7) / 15 * 15
+	if minutes >= 60 {
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), minutes, 0, 0, t.Location())
+}
+
+// Floor rounds t down to the slot it falls in
+func Floor(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()/15*15, 0, 0, t.Location())
+}
+
+// floorHour works on the wall clock, since Truncate is relative to UTC and
+// would be off in zones with a half-hour offset
+func floorHour(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+}
+
+func ceilHour(t time.Time) time.Time {
+	if h := floorHour(t); !h.Equal(t) {
+		return h.Add(time.Hour)
+	}
+	return t
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
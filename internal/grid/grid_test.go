@@ -0,0 +1,102 @@
+package grid
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yossigruner/SlotBot/internal/domain"
+)
+
+func TestNew(t *testing.T) {
+	day := time.Date(2030, 1, 9, 0, 0, 0, 0, time.UTC)
+	at := func(hour, min int) time.Time {
+		return day.Add(time.Duration(hour)*time.Hour + time.Duration(min)*time.Minute)
+	}
+
+	events := []domain.Event{
+		{Env: "staging", Service: "web", StartTime: at(9, 0), EndTime: at(10, 0)},
+		{Env: "staging", Service: "api", StartTime: at(8, 15), EndTime: at(8, 45)},
+		{Env: "staging", Service: domain.AllServices, StartTime: at(12, 0), EndTime: at(12, 30)},
+		{Env: "qa", Service: "api", StartTime: at(8, 0), EndTime: at(20, 0)},
+	}
+
+	g := New("staging", day, events)
+
+	if !g.Start.Equal(at(8, 0)) || g.Slots != 48 {
+		t.Fatalf("window = %v + %d slots, want 08:00 + 48 slots", g.Start, g.Slots)
+	}
+	if want := []string{"api", "web"}; strings.Join(g.Services, ",") != strings.Join(want, ",") {
+		t.Errorf("Services = %v, want %v", g.Services, want)
+	}
+
+	tests := []struct {
+		service string
+		slot    int
+		want    bool
+	}{
+		{"api", 0, false},
+		{"api", 1, true},
+		{"api", 2, true},
+		{"api", 3, false},
+		{"web", 4, true},
+		{"web", 7, true},
+		{"web", 8, false},
+		// The lock covers every service
+		{"api", 16, true},
+		{"web", 17, true},
+		{"web", 18, false},
+	}
+	for _, tt := range tests {
+		if got := g.IsBusy(tt.service, tt.slot); got != tt.want {
+			t.Errorf("IsBusy(%s, %d) = %v, want %v", tt.service, tt.slot, got, tt.want)
+		}
+	}
+
+	out := g.String()
+	if !strings.Contains(out, "api  .##.....") || !strings.Contains(out, "     08  09  10") {
+		t.Errorf("String() =\n%s\nwant the api row and hour scale", out)
+	}
+}
+
+func TestNewWidensWindow(t *testing.T) {
+	day := time.Date(2030, 1, 9, 0, 0, 0, 0, time.UTC)
+	events := []domain.Event{
+		{Env: "qa", Service: "db", StartTime: day.Add(6*time.Hour + 30*time.Minute), EndTime: day.Add(7 * time.Hour)},
+		{Env: "qa", Service: "db", StartTime: day.Add(22 * time.Hour), EndTime: day.Add(26 * time.Hour)},
+	}
+
+	g := New("qa", day, events)
+	if !g.Start.Equal(day.Add(6*time.Hour)) || g.Slots != 18*4 {
+		t.Errorf("window = %v + %d slots, want 06:00 to midnight", g.Start, g.Slots)
+	}
+}
+
+func TestNewEmpty(t *testing.T) {
+	g := New("demo", time.Now(), nil)
+	if !strings.Contains(g.String(), "free all day") {
+		t.Errorf("String() = %q, want a free-all-day note", g.String())
+	}
+}
+
+func TestRound(t *testing.T) {
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in, round, floor string
+	}{
+		{"10:00", "10:00", "10:00"},
+		{"10:07", "10:00", "10:00"},
+		{"10:08", "10:15", "10:00"},
+		{"10:53", "11:00", "10:45"},
+	}
+	for _, tt := range tests {
+		in, _ := time.Parse("15:04", tt.in)
+		at := day.Add(time.Duration(in.Hour())*time.Hour + time.Duration(in.Minute())*time.Minute + 30*time.Second)
+		if got := Round(at).Format("15:04"); got != tt.round {
+			t.Errorf("Round(%s) = %s, want %s", tt.in, got, tt.round)
+		}
+		if got := Floor(at).Format("15:04"); got != tt.floor {
+			t.Errorf("Floor(%s) = %s, want %s", tt.in, got, tt.floor)
+		}
+	}
+}
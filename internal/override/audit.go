@@ -0,0 +1,74 @@
+package override
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/yossigruner/SlotBot/internal/domain"
+)
+
+// Decisions recorded in the audit trail
+const (
+	Approved = "approved"
+	Denied   = "denied"
+	Failed   = "failed"
+)
+
+// Record is one decision on an override request in the audit trail
+type Record struct {
+	Time        time.Time        `json:"time"`
+	RequestID   string           `json:"request_id"`
+	Decision    string           `json:"decision"` // Approved, Denied or Failed
+	Reason      string           `json:"reason"`
+	Requester   string           `json:"requester"`
+	RequesterID string           `json:"requester_id"`
+	DecidedBy   string           `json:"decided_by"`
+	DecidedByID string           `json:"decided_by_id"`
+	ApprovedBy  []string         `json:"approved_by,omitempty"` // Slack user IDs of everyone who approved
+	Bookings    []domain.Booking `json:"bookings"`
+	Displaced   []Displacement   `json:"displaced,omitempty"`
+	Error       string           `json:"error,omitempty"`
+}
+
+// Audit appends records to a JSON Lines file, one decision per line, so the
+// trail is never rewritten.
+type Audit struct {
+	mu   sync.Mutex
+	path string
+}
+
+// OpenAudit creates an audit trail kept at path. An empty path keeps no trail.
+func OpenAudit(path string) *Audit {
+	return &Audit{path: path}
+}
+
+// Record appends rec to the trail
+func (a *Audit) Record(rec Record) error {
+	if a == nil || a.path == "" {
+		return nil
+	}
+	if rec.Time.IsZero() {
+		rec.Time = time.Now()
+	}
+
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("unable to encode audit record: %w", err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	f, err := os.OpenFile(a.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("unable to open audit trail: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("unable to write audit trail: %w", err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,297 @@
+// Package override lets urgent bookings displace the bookings in their way
+// once someone approves it, and keeps an audit trail of every decision.
+package override
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/yossigruner/SlotBot/internal/calendar"
+	"github.com/yossigruner/SlotBot/internal/domain"
+)
+
+// ErrChanged is returned by Apply when other bookings got in the way after
+// the request was made; those weren't part of what was approved.
+var ErrChanged = errors.New("the slot has changed since the request was made")
+
+// moveWindow is how far past the urgent booking a displaced booking may be
+// moved before it is cancelled instead
+const moveWindow = 7 * 24 * time.Hour
+
+// Request is an urgent booking waiting for approval to displace the
+// bookings it conflicts with
+type Request struct {
+	ID          string
+	Bookings    []domain.Booking // Booked together, one per service
+	Reason      string
+	Conflicts   []domain.Event // The bookings that would be displaced
+	Approvers   []string       // Slack user IDs who may decide
+	Unanimous   bool           // Every approver must approve, not just one
+	Approved    []string       // Approvers who have approved so far
+	Messages    []Message      // The approval messages sent, to update once decided
+	RequestedAt time.Time
+}
+
+// Message identifies an approval message posted to an approver
+type Message struct {
+	UserID  string
+	Channel string
+	TS      string
+}
+
+// Displacement is what an approved override did to a booking in its way
+type Displacement struct {
+	Event  domain.Event `json:"event"`          // The booking as it was
+	Action string       `json:"action"`         // ActionShortened, ActionMoved or ActionCancelled
+	Start  time.Time    `json:"start,omitzero"` // New start, unless cancelled
+	End    time.Time    `json:"end,omitzero"`   // New end, unless cancelled
+}
+
+// What happened to a displaced booking
+const (
+	ActionShortened = "shortened"
+	ActionMoved     = "moved"
+	ActionCancelled = "cancelled"
+)
+
+// Pending holds the requests waiting for a decision. They are kept in
+// memory only: a restart drops them, and nothing has changed yet.
+type Pending struct {
+	ttl time.Duration
+
+	mu       sync.Mutex
+	requests map[string]Request
+}
+
+// NewPending creates a store whose requests expire after ttl
+func NewPending(ttl time.Duration) *Pending {
+	return &Pending{ttl: ttl, requests: make(map[string]Request)}
+}
+
+// Add stores r under a new ID and returns it
+func (p *Pending) Add(r Request) Request {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	r.ID = newRequestID()
+	if r.RequestedAt.IsZero() {
+		r.RequestedAt = time.Now()
+	}
+	p.requests[r.ID] = r
+	return r
+}
+
+// Get returns the request if it is still waiting
+func (p *Pending) Get(id string) (Request, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	r, ok := p.requests[id]
+	if !ok || time.Since(r.RequestedAt) > p.ttl {
+		return Request{}, false
+	}
+	return r, true
+}
+
+// SetMessages records where the approval messages for a request were posted
+func (p *Pending) SetMessages(id string, messages []Message) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if r, ok := p.requests[id]; ok {
+		r.Messages = messages
+		p.requests[id] = r
+	}
+}
+
+// Take removes the request and returns it, so only the first decision counts.
+// Expired requests are dropped and reported as missing.
+func (p *Pending) Take(id string) (Request, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	r, ok := p.requests[id]
+	delete(p.requests, id)
+	for other, req := range p.requests {
+		if time.Since(req.RequestedAt) > p.ttl {
+			delete(p.requests, other)
+		}
+	}
+	if !ok || time.Since(r.RequestedAt) > p.ttl {
+		return Request{}, false
+	}
+	return r, true
+}
+
+// Approve records userID's approval of the request. Once it is approved,
+// by every approver if it is Unanimous and otherwise by userID alone, the
+// request is removed and returned with done set, so it is carried out only
+// once. Expired requests are reported as missing.
+func (p *Pending) Approve(id, userID string) (r Request, done, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	r, ok = p.requests[id]
+	if !ok || time.Since(r.RequestedAt) > p.ttl {
+		return Request{}, false, false
+	}
+	if !slices.Contains(r.Approved, userID) {
+		r.Approved = append(slices.Clone(r.Approved), userID)
+	}
+	if r.Unanimous && len(r.Waiting()) > 0 {
+		p.requests[id] = r
+		return r, false, true
+	}
+	delete(p.requests, id)
+	return r, true, true
+}
+
+// MayDecide reports whether the user may approve or deny r
+func (r Request) MayDecide(userID string) bool {
+	return userID != "" && slices.Contains(r.Approvers, userID)
+}
+
+// Waiting returns the approvers who have not approved r yet
+func (r Request) Waiting() []string {
+	return slices.DeleteFunc(slices.Clone(r.Approvers), func(id string) bool {
+		return slices.Contains(r.Approved, id)
+	})
+}
+
+// Apply books the approved request and makes room for it. Bookings that
+// started before it are cut short; the others move to the next free slot
+// after it, or are cancelled if there is none within a week. It returns
+// ErrChanged, without changing anything, if bookings other than the
+// approved ones are now in the way. rules decide where displaced bookings
+// may move. If any step fails, what was done is undone as far as possible,
+// so no booking is left displaced for an urgent booking that didn't happen.
+func Apply(ctx context.Context, store calendar.BookingStore, rules *calendar.Rules, r Request) ([]domain.Event, []Displacement, error) {
+	start, end := span(r.Bookings)
+	events, err := store.ListEvents(ctx, start.Add(-24*time.Hour), end.Add(moveWindow))
+	if err != nil {
+		return nil, nil, fmt.Errorf("list events: %w", err)
+	}
+
+	var inTheWay []domain.Event
+	for _, b := range r.Bookings {
+		for _, e := range rules.Conflicts(b, events) {
+			if !slices.ContainsFunc(r.Conflicts, func(c domain.Event) bool { return c.ID == e.ID }) {
+				return nil, nil, ErrChanged
+			}
+			if !slices.ContainsFunc(inTheWay, func(c domain.Event) bool { return c.ID == e.ID }) {
+				inTheWay = append(inTheWay, e)
+			}
+		}
+	}
+
+	// Displaced bookings can't move into the urgent booking's own time
+	remaining := slices.DeleteFunc(slices.Clone(events), func(e domain.Event) bool {
+		return slices.ContainsFunc(inTheWay, func(c domain.Event) bool { return c.ID == e.ID })
+	})
+	for _, b := range r.Bookings {
+		remaining = append(remaining, domain.Event{Env: b.Env, Service: b.Service, StartTime: b.StartTime, EndTime: b.StartTime.Add(b.Duration)})
+	}
+
+	// The store doesn't check for conflicts, so booking first means a
+	// failure here leaves everyone else's bookings untouched
+	created, err := calendar.CreateBookings(ctx, store, r.Bookings)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var displaced []Displacement
+	for _, e := range inTheWay {
+		d, err := displace(ctx, store, rules, e, start, end, remaining)
+		if err != nil {
+			restore(ctx, store, displaced)
+			for _, c := range created {
+				if delErr := store.DeleteEvent(ctx, c.ID); delErr != nil {
+					slog.Error("Failed to roll back urgent booking", "error", delErr, "event_id", c.ID, "env", c.Env, "service", c.Service)
+				}
+			}
+			return nil, nil, err
+		}
+		displaced = append(displaced, d)
+		if d.Action != ActionCancelled {
+			moved := e
+			moved.StartTime, moved.EndTime = d.Start, d.End
+			remaining = append(remaining, moved)
+		}
+	}
+	return created, displaced, nil
+}
+
+// restore undoes displacements: shortened and moved bookings get their
+// times back and cancelled ones are booked again
+func restore(ctx context.Context, store calendar.BookingStore, displaced []Displacement) {
+	for _, d := range displaced {
+		e := d.Event
+		var err error
+		if d.Action == ActionCancelled {
+			_, err = store.CreateEvent(ctx, e.Booking())
+		} else {
+			_, err = store.UpdateEvent(ctx, e.ID, e.Booking())
+		}
+		if err != nil {
+			slog.Error("Failed to restore displaced booking", "error", err, "event_id", e.ID, "action", d.Action, "env", e.Env, "service", e.Service, "user", e.User)
+		}
+	}
+}
+
+// displace shortens, moves or cancels e to clear [start, end)
+func displace(ctx context.Context, store calendar.BookingStore, rules *calendar.Rules, e domain.Event, start, end time.Time, events []domain.Event) (Displacement, error) {
+	d := Displacement{Event: e}
+	b := e.Booking()
+
+	if e.StartTime.Before(start) {
+		b.Duration = start.Sub(e.StartTime)
+		d.Action = ActionShortened
+	} else {
+		slots, err := rules.FreeSlots(e.Env, []string{e.Service}, b.Duration, events, end, 1)
+		if err != nil || slots[0].Start.Add(b.Duration).After(end.Add(moveWindow)) {
+			if err := store.DeleteEvent(ctx, e.ID); err != nil {
+				return d, fmt.Errorf("cancel %s: %w", e.ID, err)
+			}
+			d.Action = ActionCancelled
+			slog.Info("Cancelled booking for urgent override", "event_id", e.ID, "env", e.Env, "service", e.Service, "user", e.User)
+			return d, nil
+		}
+		b.StartTime = slots[0].Start
+		d.Action = ActionMoved
+	}
+
+	updated, err := store.UpdateEvent(ctx, e.ID, b)
+	if err != nil {
+		return d, fmt.Errorf("update %s: %w", e.ID, err)
+	}
+	d.Start, d.End = updated.StartTime, updated.EndTime
+	slog.Info("Displaced booking for urgent override", "event_id", e.ID, "action", d.Action, "env", e.Env, "service", e.Service, "user", e.User)
+	return d, nil
+}
+
+// span returns when the bookings start and end together
+func span(bookings []domain.Booking) (time.Time, time.Time) {
+	start, end := bookings[0].StartTime, bookings[0].StartTime.Add(bookings[0].Duration)
+	for _, b := range bookings[1:] {
+		if b.StartTime.Before(start) {
+			start = b.StartTime
+		}
+		if e := b.StartTime.Add(b.Duration); e.After(end) {
+			end = e
+		}
+	}
+	return start, end
+}
+
+func newRequestID() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
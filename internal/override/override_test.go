@@ -0,0 +1,228 @@
+package override
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yossigruner/SlotBot/internal/calendar"
+	"github.com/yossigruner/SlotBot/internal/domain"
+)
+
+func TestApply(t *testing.T) {
+	ctx := context.Background()
+	day := time.Now().Truncate(time.Hour).Add(24 * time.Hour)
+	at := func(h int) time.Time { return day.Add(time.Duration(h) * time.Hour) }
+
+	store := calendar.NewMemoryStore()
+	create := func(service string, start time.Time, d time.Duration, user string) domain.Event {
+		t.Helper()
+		e, err := store.CreateEvent(ctx, domain.Booking{Env: "staging", Service: service, JiraTicket: "OG-1", StartTime: start, Duration: d, User: user, UserID: "U-" + user})
+		if err != nil {
+			t.Fatalf("CreateEvent() error = %v", err)
+		}
+		return *e
+	}
+
+	// bob's api booking started first and is cut short, carol's web booking
+	// moves past both the urgent booking and dave's
+	bob := create("api", at(0), 2*time.Hour, "bob")
+	carol := create("web", at(1), time.Hour, "carol")
+	create("web", at(2), time.Hour, "dave")
+
+	urgent := []domain.Booking{
+		{Env: "staging", Service: "api", JiraTicket: "OG-911", StartTime: at(1), Duration: time.Hour, User: "alice", UserID: "U-alice"},
+		{Env: "staging", Service: "web", JiraTicket: "OG-911", StartTime: at(1), Duration: time.Hour, User: "alice", UserID: "U-alice"},
+	}
+	req := Request{Bookings: urgent, Reason: "hotfix", Conflicts: []domain.Event{bob, carol}}
+
+	created, displaced, err := Apply(ctx, store, calendar.NewRules(nil, nil, nil), req)
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if len(created) != 2 {
+		t.Errorf("Apply() created %d bookings, want 2", len(created))
+	}
+
+	want := map[string]Displacement{
+		bob.ID:   {Action: ActionShortened, Start: at(0), End: at(1)},
+		carol.ID: {Action: ActionMoved, Start: at(3), End: at(4)},
+	}
+	if len(displaced) != len(want) {
+		t.Fatalf("Apply() displaced %d bookings, want %d", len(displaced), len(want))
+	}
+	for _, d := range displaced {
+		w := want[d.Event.ID]
+		if d.Action != w.Action || !d.Start.Equal(w.Start) || !d.End.Equal(w.End) {
+			t.Errorf("displaced %s = %s %v-%v, want %s %v-%v", d.Event.User, d.Action, d.Start, d.End, w.Action, w.Start, w.End)
+		}
+	}
+
+	// A booking nobody approved is in the way now
+	create("db", at(6), time.Hour, "erin")
+	later := Request{
+		Bookings: []domain.Booking{{Env: "staging", Service: domain.AllServices, StartTime: at(6), Duration: time.Hour, UserID: "U-alice"}},
+	}
+	if _, _, err := Apply(ctx, store, calendar.NewRules(nil, nil, nil), later); !errors.Is(err, ErrChanged) {
+		t.Errorf("Apply() with an unapproved conflict error = %v, want ErrChanged", err)
+	}
+}
+
+// failingStore fails every CreateEvent after the first createsLeft, and
+// every UpdateEvent of failUser's bookings.
+type failingStore struct {
+	*calendar.MemoryStore
+	createsLeft int
+	failUser    string
+}
+
+func (f *failingStore) CreateEvent(ctx context.Context, booking domain.Booking) (*domain.Event, error) {
+	if f.createsLeft == 0 {
+		return nil, errors.New("calendar unavailable")
+	}
+	f.createsLeft--
+	return f.MemoryStore.CreateEvent(ctx, booking)
+}
+
+func (f *failingStore) UpdateEvent(ctx context.Context, id string, booking domain.Booking) (*domain.Event, error) {
+	if booking.User == f.failUser {
+		return nil, errors.New("calendar unavailable")
+	}
+	return f.MemoryStore.UpdateEvent(ctx, id, booking)
+}
+
+func TestApplyFailureChangesNothing(t *testing.T) {
+	ctx := context.Background()
+	day := time.Now().Truncate(time.Hour).Add(24 * time.Hour)
+	at := func(h int) time.Time { return day.Add(time.Duration(h) * time.Hour) }
+
+	tests := []struct {
+		name        string
+		createsLeft int
+		failUser    string
+	}{
+		{"Second urgent booking fails", 1, ""},
+		{"Moving a booking fails", 2, "carol"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mem := calendar.NewMemoryStore()
+			var before []domain.Event
+			for _, b := range []domain.Booking{
+				{Env: "staging", Service: "api", StartTime: at(0), Duration: 2 * time.Hour, User: "bob", UserID: "U-bob"},
+				{Env: "staging", Service: "web", StartTime: at(1), Duration: time.Hour, User: "carol", UserID: "U-carol"},
+			} {
+				e, err := mem.CreateEvent(ctx, b)
+				if err != nil {
+					t.Fatalf("CreateEvent() error = %v", err)
+				}
+				before = append(before, *e)
+			}
+
+			store := &failingStore{MemoryStore: mem, createsLeft: tt.createsLeft, failUser: tt.failUser}
+			req := Request{
+				Bookings: []domain.Booking{
+					{Env: "staging", Service: "api", StartTime: at(1), Duration: time.Hour, UserID: "U-alice"},
+					{Env: "staging", Service: "web", StartTime: at(1), Duration: time.Hour, UserID: "U-alice"},
+				},
+				Conflicts: before,
+			}
+			created, displaced, err := Apply(ctx, store, calendar.NewRules(nil, nil, nil), req)
+			if err == nil || created != nil || displaced != nil {
+				t.Fatalf("Apply() = %v, %v, %v, want only an error", created, displaced, err)
+			}
+
+			after, _ := mem.ListEvents(ctx, at(-1), at(24*8))
+			if len(after) != len(before) {
+				t.Fatalf("ListEvents() after failure returned %d events, want %d", len(after), len(before))
+			}
+			for i, e := range after {
+				if e.User != before[i].User || !e.StartTime.Equal(before[i].StartTime) || !e.EndTime.Equal(before[i].EndTime) {
+					t.Errorf("%s's booking = %v-%v, want it unchanged at %v-%v", e.User, e.StartTime, e.EndTime, before[i].StartTime, before[i].EndTime)
+				}
+			}
+		})
+	}
+}
+
+func TestPending(t *testing.T) {
+	p := NewPending(time.Hour)
+	r := p.Add(Request{Reason: "hotfix", Approvers: []string{"U1"}})
+
+	if got, ok := p.Get(r.ID); !ok || got.Reason != "hotfix" {
+		t.Fatalf("Get() = %+v, %v, want the request", got, ok)
+	}
+	if !r.MayDecide("U1") || r.MayDecide("U2") || r.MayDecide("") {
+		t.Errorf("MayDecide() should only allow U1")
+	}
+	if _, ok := p.Take(r.ID); !ok {
+		t.Errorf("Take() should return the request")
+	}
+	if _, ok := p.Take(r.ID); ok {
+		t.Errorf("Take() twice should only count the first decision")
+	}
+
+	old := p.Add(Request{RequestedAt: time.Now().Add(-2 * time.Hour)})
+	if _, ok := p.Get(old.ID); ok {
+		t.Errorf("Get() should not return an expired request")
+	}
+	if _, _, ok := p.Approve(old.ID, "U1"); ok {
+		t.Errorf("Approve() should not approve an expired request")
+	}
+}
+
+func TestPendingApprove(t *testing.T) {
+	p := NewPending(time.Hour)
+
+	one := p.Add(Request{Approvers: []string{"U1", "U2"}})
+	if r, done, ok := p.Approve(one.ID, "U2"); !ok || !done || strings.Join(r.Approved, ",") != "U2" {
+		t.Errorf("Approve() = %+v, %v, %v, want one approval to be enough", r, done, ok)
+	}
+
+	all := p.Add(Request{Approvers: []string{"U1", "U2"}, Unanimous: true})
+	for range 2 {
+		if r, done, ok := p.Approve(all.ID, "U1"); !ok || done || strings.Join(r.Waiting(), ",") != "U2" {
+			t.Errorf("Approve() = %+v, %v, %v, want it still waiting for U2", r, done, ok)
+		}
+	}
+	if r, done, ok := p.Approve(all.ID, "U2"); !ok || !done || strings.Join(r.Approved, ",") != "U1,U2" {
+		t.Errorf("Approve() = %+v, %v, %v, want it approved by both", r, done, ok)
+	}
+	if _, ok := p.Get(all.ID); ok {
+		t.Errorf("Get() should not return an approved request")
+	}
+}
+
+func TestAudit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	audit := OpenAudit(path)
+
+	for _, decision := range []string{Approved, Denied} {
+		if err := audit.Record(Record{RequestID: "r1", Decision: decision, Reason: "hotfix"}); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("audit trail has %d lines, want 2", len(lines))
+	}
+	var rec Record
+	if err := json.Unmarshal([]byte(lines[1]), &rec); err != nil || rec.Decision != Denied || rec.Time.IsZero() {
+		t.Errorf("second record = %+v, %v, want a timestamped denial", rec, err)
+	}
+
+	if err := OpenAudit("").Record(Record{}); err != nil {
+		t.Errorf("Record() without a path error = %v, want nil", err)
+	}
+}
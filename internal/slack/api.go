@@ -0,0 +1,170 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const slackAPIURL = "https://slack.com/api/"
+
+// APIClient is a minimal client for the Slack Web API, authenticated with the bot token.
+type APIClient struct {
+	token      string
+	baseURL    string
+	httpClient *http.Client
+}
+
+func NewAPIClient(token string) *APIClient {
+	return &APIClient{
+		token:      token,
+		baseURL:    slackAPIURL,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// apiResponse holds the fields every Web API response has
+type apiResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error"`
+}
+
+// call POSTs a JSON payload to a Web API method and decodes the response into out.
+func (c *APIClient) call(ctx context.Context, method string, payload any, out any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("slack %s: %w", method, err)
+	}
+	return c.do(ctx, method, "application/json; charset=utf-8", bytes.NewReader(body), out)
+}
+
+// callForm POSTs form arguments, for read methods like users.info that
+// don't accept JSON bodies.
+func (c *APIClient) callForm(ctx context.Context, method string, args url.Values, out any) error {
+	return c.do(ctx, method, "application/x-www-form-urlencoded", strings.NewReader(args.Encode()), out)
+}
+
+func (c *APIClient) do(ctx context.Context, method, contentType string, body io.Reader, out any) error {
+	if c.token == "" {
+		return fmt.Errorf("slack %s: SLACK_BOT_TOKEN is not set", method)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+method, body)
+	if err != nil {
+		return fmt.Errorf("slack %s: %w", method, err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("slack %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack %s: unexpected status %s", method, resp.Status)
+	}
+
+	raw := new(bytes.Buffer)
+	if _, err := raw.ReadFrom(resp.Body); err != nil {
+		return fmt.Errorf("slack %s: %w", method, err)
+	}
+
+	var status apiResponse
+	if err := json.Unmarshal(raw.Bytes(), &status); err != nil {
+		return fmt.Errorf("slack %s: invalid response: %w", method, err)
+	}
+	if !status.OK {
+		return fmt.Errorf("slack %s: %s", method, status.Error)
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(raw.Bytes(), out); err != nil {
+			return fmt.Errorf("slack %s: invalid response: %w", method, err)
+		}
+	}
+	return nil
+}
+
+// PostMessage posts text to a channel. Using a user ID as the channel sends
+// a direct message from the bot.
+func (c *APIClient) PostMessage(ctx context.Context, channel, text string) error {
+	return c.call(ctx, "chat.postMessage", map[string]string{
+		"channel": channel,
+		"text":    text,
+	}, nil)
+}
+
+// SendDirectMessage sends text to a Slack user from the bot.
+func (c *APIClient) SendDirectMessage(ctx context.Context, userID, text string) error {
+	return c.PostMessage(ctx, userID, text)
+}
+
+// UserTimezone returns the IANA timezone name from a user's Slack profile,
+// e.g. "America/New_York".
+func (c *APIClient) UserTimezone(ctx context.Context, userID string) (string, error) {
+	var resp struct {
+		User struct {
+			TZ string `json:"tz"`
+		} `json:"user"`
+	}
+	if err := c.callForm(ctx, "users.info", url.Values{"user": {userID}}, &resp); err != nil {
+		return "", err
+	}
+	return resp.User.TZ, nil
+}
+
+// UserGroups returns the workspace's user groups by handle, with the user
+// IDs of their members.
+func (c *APIClient) UserGroups(ctx context.Context) (map[string][]string, error) {
+	var resp struct {
+		Usergroups []struct {
+			Handle string   `json:"handle"`
+			Users  []string `json:"users"`
+		} `json:"usergroups"`
+	}
+	if err := c.callForm(ctx, "usergroups.list", url.Values{"include_users": {"true"}}, &resp); err != nil {
+		return nil, err
+	}
+
+	groups := make(map[string][]string, len(resp.Usergroups))
+	for _, g := range resp.Usergroups {
+		groups[g.Handle] = g.Users
+	}
+	return groups, nil
+}
+
+// PostBlocks posts a Block Kit message, with text as the notification
+// fallback, and returns the channel and timestamp that identify it.
+func (c *APIClient) PostBlocks(ctx context.Context, channel, text string, blocks []map[string]any) (string, string, error) {
+	var resp struct {
+		Channel string `json:"channel"`
+		TS      string `json:"ts"`
+	}
+	if err := c.call(ctx, "chat.postMessage", map[string]any{
+		"channel": channel,
+		"text":    text,
+		"blocks":  blocks,
+	}, &resp); err != nil {
+		return "", "", err
+	}
+	return resp.Channel, resp.TS, nil
+}
+
+// UpdateMessage replaces a message's content with text, dropping its blocks
+// so buttons can't be pressed again.
+func (c *APIClient) UpdateMessage(ctx context.Context, channel, ts, text string) error {
+	return c.call(ctx, "chat.update", map[string]any{
+		"channel": channel,
+		"ts":      ts,
+		"text":    text,
+		"blocks":  []any{},
+	}, nil)
+}
@@ -0,0 +1,442 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/yossigruner/SlotBot/internal/calendar"
+	"github.com/yossigruner/SlotBot/internal/domain"
+	"github.com/yossigruner/SlotBot/internal/grid"
+)
+
+// bookingLookahead is how far ahead commands search for a user's bookings
+const bookingLookahead = 7 * 24 * time.Hour
+
+// errUserFacing marks errors whose message can be shown to the user as-is.
+type errUserFacing struct {
+	msg string
+}
+
+func (e errUserFacing) Error() string {
+	return e.msg
+}
+
+func userError(format string, args ...any) error {
+	return errUserFacing{msg: fmt.Sprintf(format, args...)}
+}
+
+// respondError replies with a user-facing error, or a generic message for
+// unexpected errors, which are logged.
+func respondError(w http.ResponseWriter, err error, action string) {
+	var ue errUserFacing
+	if errors.As(err, &ue) {
+		respond(w, "❌ "+ue.msg)
+		return
+	}
+	slog.Error("Failed to "+action, "error", err)
+	respond(w, "❌ Failed to "+action)
+}
+
+// isOwner reports whether the Slack user made the booking. Events created
+// before the Slack user ID was stored fall back to the user name in the title.
+func isOwner(event domain.Event, userID, userName string) bool {
+	if event.UserID != "" {
+		return event.UserID == userID
+	}
+	return event.User != "" && strings.EqualFold(event.User, userName)
+}
+
+// findUserBooking resolves the caller's current or upcoming booking from
+// args, which hold either a booking ID, an env and service (names or
+// aliases), or nothing (the caller's only booking). The ID of a recurring booking resolves to its
+// current or next occurrence. With activeOnly set, upcoming bookings are ignored.
+func (h *Handler) findUserBooking(ctx context.Context, r *http.Request, args []string, activeOnly bool) (*domain.Event, error) {
+	loc := h.userLocation(r)
+	userID := r.FormValue("user_id")
+	userName := r.FormValue("user_name")
+
+	now := time.Now()
+	events, err := h.store.ListEvents(ctx, now, now.Add(bookingLookahead))
+	if err != nil {
+		return nil, fmt.Errorf("list events: %w", err)
+	}
+
+	// Bookings made for "now" are rounded to the slot grid and can start a
+	// few minutes ahead; they still count as active.
+	started := func(event domain.Event) bool {
+		return !event.StartTime.After(grid.Round(now))
+	}
+
+	if len(args) == 1 {
+		// An occurrence's own ID, or else the series' earliest one
+		var found *domain.Event
+		for i, event := range events {
+			if event.ID == args[0] {
+				found = &events[i]
+				break
+			}
+			if found == nil && calendar.IsOccurrenceOf(event.ID, args[0]) && (!activeOnly || started(event)) {
+				found = &events[i]
+			}
+		}
+		if found == nil {
+			return nil, userError("No current or upcoming booking with ID %s", args[0])
+		}
+		if !isOwner(*found, userID, userName) {
+			return nil, userError("Booking %s belongs to someone else - you can only change your own bookings", args[0])
+		}
+		if activeOnly && !started(*found) {
+			return nil, userError("Booking %s has not started yet", args[0])
+		}
+		return found, nil
+	}
+
+	var env, service string
+	if len(args) >= 2 {
+		var services []string
+		if env, services, err = h.lookupNames(args[0], []string{normalizeService(args[1])}); err != nil {
+			return nil, err
+		}
+		service = services[0]
+	}
+
+	var matches []domain.Event
+	for _, event := range events {
+		if !isOwner(event, userID, userName) {
+			continue
+		}
+		if activeOnly && !started(event) {
+			continue
+		}
+		if len(args) >= 2 && (!strings.EqualFold(event.Env, env) || !strings.EqualFold(event.Service, service)) {
+			continue
+		}
+		matches = append(matches, event)
+	}
+
+	what := "current or upcoming"
+	if activeOnly {
+		what = "active"
+	}
+
+	switch {
+	case len(matches) == 0 && len(args) >= 2:
+		return nil, userError("You have no %s booking for %s / %s", what, env, displayService(service))
+	case len(matches) == 0:
+		return nil, userError("You have no %s bookings", what)
+	case len(args) < 2 && len(matches) > 1:
+		return nil, userError("You have %d %s bookings - please specify which one:\n```\n%s```",
+			len(matches), what, formatEventsTable(matches, loc))
+	}
+
+	// Events are sorted by start, so this is the current or soonest booking
+	return &matches[0], nil
+}
+
+func (h *Handler) handleCancelSubcommand(w http.ResponseWriter, r *http.Request, args []string) {
+	loc := h.userLocation(r)
+	if len(args) > 2 {
+		respond(w, "Usage: `/slot cancel [booking-id | env service]`")
+		return
+	}
+
+	if h.store == nil {
+		respond(w, "❌ Calendar not configured. Please set up Google Calendar credentials (see SERVICE_ACCOUNT_SETUP.md)")
+		return
+	}
+
+	event, err := h.findUserBooking(r.Context(), r, args, false)
+	if err != nil {
+		respondError(w, err, "find your booking")
+		return
+	}
+
+	// A recurring booking's own ID cancels the whole series
+	id := event.ID
+	series := len(args) == 1 && args[0] != event.ID
+	if series {
+		id = args[0]
+	}
+
+	if err := h.store.DeleteEvent(r.Context(), id); err != nil {
+		if errors.Is(err, calendar.ErrNotFound) {
+			respond(w, "❌ The booking no longer exists")
+			return
+		}
+		slog.Error("Failed to delete calendar event", "error", err, "event_id", id)
+		respond(w, "❌ Failed to cancel booking")
+		return
+	}
+
+	slog.Info("Booking cancelled", "env", event.Env, "service", event.Service, "event_id", id, "user", r.FormValue("user_name"))
+	h.releaseToWaitlist(event.Env)
+
+	if series {
+		respond(w, fmt.Sprintf("🗑️ Cancelled the whole recurring booking. Its next occurrence was:\n```\n%s```", formatEventsTable([]domain.Event{*event}, loc)))
+		return
+	}
+	respond(w, fmt.Sprintf("🗑️ Cancelled!\n```\n%s```", formatEventsTable([]domain.Event{*event}, loc)))
+}
+
+func (h *Handler) handleExtendSubcommand(w http.ResponseWriter, r *http.Request, args []string) {
+	loc := h.userLocation(r)
+	if len(args) == 0 || len(args) > 3 {
+		respond(w, "Usage: `/slot extend [booking-id | env service] <duration>`")
+		return
+	}
+
+	extra, err := time.ParseDuration(args[len(args)-1])
+	if err != nil || extra <= 0 {
+		respond(w, fmt.Sprintf("❌ Invalid duration: %s. Use a value like 30m or 1h", args[len(args)-1]))
+		return
+	}
+
+	if h.store == nil {
+		respond(w, "❌ Calendar not configured. Please set up Google Calendar credentials (see SERVICE_ACCOUNT_SETUP.md)")
+		return
+	}
+
+	event, err := h.findUserBooking(r.Context(), r, args[:len(args)-1], true)
+	if err != nil {
+		respondError(w, err, "find your booking")
+		return
+	}
+
+	booking := event.Booking()
+	booking.Duration += extra
+
+	if err := h.rules.ValidateBooking(booking); err != nil {
+		respond(w, fmt.Sprintf("❌ Cannot extend: %v", err))
+		return
+	}
+
+	// Only the time being added can clash with other bookings
+	newEnd := booking.StartTime.Add(booking.Duration)
+	events, err := h.store.ListEvents(r.Context(), event.EndTime, newEnd)
+	if err != nil {
+		slog.Error("Failed to list calendar events", "error", err)
+		respond(w, "❌ Failed to check calendar")
+		return
+	}
+
+	if conflict := h.rules.CheckConflict(booking, events); conflict != nil {
+		respond(w, fmt.Sprintf("❌ Cannot extend - the next booking starts at %s:\n```\n%s```",
+			conflict.StartTime.In(loc).Format("15:04"),
+			formatEventsTable([]domain.Event{*conflict}, loc)))
+		return
+	}
+
+	if !h.checkQuota(w, r, booking) {
+		return
+	}
+
+	updated, err := h.store.UpdateEvent(r.Context(), event.ID, booking)
+	if err != nil {
+		respondError(w, err, "extend booking")
+		return
+	}
+
+	slog.Info("Booking extended", "env", updated.Env, "service", updated.Service, "event_id", updated.ID, "by", extra)
+
+	respond(w, fmt.Sprintf("⏩ Extended by %s!\n```\n%s```%s", extra, formatEventsTable([]domain.Event{*updated}, loc), h.homeTimes([]domain.Event{*updated}, loc)))
+}
+
+func (h *Handler) handleDoneSubcommand(w http.ResponseWriter, r *http.Request, args []string) {
+	loc := h.userLocation(r)
+	if len(args) > 2 {
+		respond(w, "Usage: `/slot done [booking-id | env service]`")
+		return
+	}
+
+	if h.store == nil {
+		respond(w, "❌ Calendar not configured. Please set up Google Calendar credentials (see SERVICE_ACCOUNT_SETUP.md)")
+		return
+	}
+
+	event, err := h.findUserBooking(r.Context(), r, args, true)
+	if err != nil {
+		respondError(w, err, "find your booking")
+		return
+	}
+
+	// Round down so the environment is free immediately
+	newEnd := grid.Floor(time.Now().In(loc))
+
+	if !newEnd.After(event.StartTime) {
+		// Nothing of the booking is left on the slot grid, so drop it entirely
+		if err := h.store.DeleteEvent(r.Context(), event.ID); err != nil {
+			respondError(w, err, "release booking")
+			return
+		}
+
+		slog.Info("Booking released", "env", event.Env, "service", event.Service, "event_id", event.ID)
+		h.releaseToWaitlist(event.Env)
+		respond(w, fmt.Sprintf("🏁 Released! %s / %s is free again.\n```\n%s```",
+			event.Env, event.Service, formatEventsTable([]domain.Event{*event}, loc)))
+		return
+	}
+
+	booking := event.Booking()
+	booking.Duration = newEnd.Sub(booking.StartTime)
+
+	updated, err := h.store.UpdateEvent(r.Context(), event.ID, booking)
+	if err != nil {
+		respondError(w, err, "release booking")
+		return
+	}
+
+	slog.Info("Booking released early", "env", updated.Env, "service", updated.Service, "event_id", updated.ID, "freed", event.EndTime.Sub(newEnd))
+	h.releaseToWaitlist(updated.Env)
+
+	respond(w, fmt.Sprintf("🏁 Released! %s / %s is free from %s.\n```\n%s```",
+		updated.Env, updated.Service, newEnd.Format("15:04"), formatEventsTable([]domain.Event{*updated}, loc)))
+}
+
+func (h *Handler) handleMineSubcommand(w http.ResponseWriter, r *http.Request, args []string) {
+	loc := h.userLocation(r)
+	userID := r.FormValue("user_id")
+	userName := r.FormValue("user_name")
+
+	if h.store == nil {
+		respond(w, "❌ Calendar not configured. Please set up Google Calendar credentials (see SERVICE_ACCOUNT_SETUP.md)")
+		return
+	}
+
+	now := time.Now()
+	events, err := h.store.ListEvents(r.Context(), now, now.Add(h.mineHorizon))
+	if err != nil {
+		slog.Error("Failed to list calendar events", "error", err)
+		respond(w, "❌ Failed to check calendar")
+		return
+	}
+
+	var mine []domain.Event
+	for _, event := range events {
+		if isOwner(event, userID, userName) {
+			mine = append(mine, event)
+		}
+	}
+
+	days := int(h.mineHorizon.Hours() / 24)
+	if len(mine) == 0 {
+		respond(w, fmt.Sprintf("📅 You have no bookings in the next %d days", days))
+		return
+	}
+
+	var response strings.Builder
+	response.WriteString(fmt.Sprintf("📅 Your bookings for the next %d days (%d), times in %s:\n\n", days, len(mine), loc))
+	response.WriteString("```\n")
+	response.WriteString(formatBookingsTable(mine, loc))
+	response.WriteString("```\n")
+	response.WriteString("Use the ID with `/slot cancel`, `/slot extend`, `/slot move`, `/slot edit` or `/slot done`")
+
+	respond(w, response.String())
+}
+
+func (h *Handler) handleMoveSubcommand(w http.ResponseWriter, r *http.Request, args []string) {
+	loc := h.userLocation(r)
+	if len(args) < 2 {
+		respond(w, "Usage: `/slot move <booking-id> <start> [duration]`")
+		return
+	}
+
+	startTime, duration, err := parseWhen(args[1:], time.Now().In(loc))
+	if err != nil {
+		respond(w, fmt.Sprintf("❌ Invalid start time: %v", err))
+		return
+	}
+
+	if h.store == nil {
+		respond(w, "❌ Calendar not configured. Please set up Google Calendar credentials (see SERVICE_ACCOUNT_SETUP.md)")
+		return
+	}
+
+	event, err := h.findUserBooking(r.Context(), r, args[:1], false)
+	if err != nil {
+		respondError(w, err, "find your booking")
+		return
+	}
+
+	booking := event.Booking()
+	booking.StartTime = grid.Round(startTime)
+	if duration > 0 {
+		booking.Duration = duration
+	}
+
+	h.saveChangedBooking(w, r, booking, "⏱️ Moved!")
+}
+
+func (h *Handler) handleEditSubcommand(w http.ResponseWriter, r *http.Request, args []string) {
+	if len(args) != 2 {
+		respond(w, "Usage: `/slot edit <booking-id> jira=<ticket>`")
+		return
+	}
+
+	key, value, ok := strings.Cut(args[1], "=")
+	if !ok || !strings.EqualFold(key, "jira") {
+		respond(w, fmt.Sprintf("❌ Unknown field: %s. Only `jira=<ticket>` can be edited", args[1]))
+		return
+	}
+	if !jiraRegex.MatchString(value) {
+		respond(w, "❌ Invalid Jira ticket format. Must be like PROJ-123 or OG-1234")
+		return
+	}
+
+	if h.store == nil {
+		respond(w, "❌ Calendar not configured. Please set up Google Calendar credentials (see SERVICE_ACCOUNT_SETUP.md)")
+		return
+	}
+
+	event, err := h.findUserBooking(r.Context(), r, args[:1], false)
+	if err != nil {
+		respondError(w, err, "find your booking")
+		return
+	}
+
+	booking := event.Booking()
+	booking.JiraTicket = value
+
+	h.saveChangedBooking(w, r, booking, "✏️ Updated!")
+}
+
+// saveChangedBooking validates an existing booking after a change, checks it
+// against every other booking and updates it in place.
+func (h *Handler) saveChangedBooking(w http.ResponseWriter, r *http.Request, booking domain.Booking, title string) {
+	loc := h.userLocation(r)
+	if err := h.rules.ValidateBooking(booking); err != nil {
+		respond(w, fmt.Sprintf("❌ Validation error: %v", err))
+		return
+	}
+
+	events, err := h.store.ListEvents(r.Context(), booking.StartTime, booking.StartTime.Add(booking.Duration))
+	if err != nil {
+		slog.Error("Failed to list calendar events", "error", err)
+		respond(w, "❌ Failed to check calendar")
+		return
+	}
+
+	if conflict := h.rules.CheckConflict(booking, events); conflict != nil {
+		respond(w, fmt.Sprintf("❌ Conflict detected! Your booking was not changed.\n```\n%s```",
+			formatEventsTable([]domain.Event{*conflict}, loc)))
+		return
+	}
+
+	if !h.checkQuota(w, r, booking) {
+		return
+	}
+
+	updated, err := h.store.UpdateEvent(r.Context(), booking.ID, booking)
+	if err != nil {
+		respondError(w, err, "update booking")
+		return
+	}
+
+	slog.Info("Booking updated", "env", updated.Env, "service", updated.Service, "event_id", updated.ID, "user", r.FormValue("user_name"))
+
+	respond(w, fmt.Sprintf("%s\n```\n%s```%s", title, formatBookingsTable([]domain.Event{*updated}, loc), h.homeTimes([]domain.Event{*updated}, loc)))
+}
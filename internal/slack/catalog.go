@@ -0,0 +1,156 @@
+package slack
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/yossigruner/SlotBot/internal/calendar"
+	"github.com/yossigruner/SlotBot/internal/domain"
+)
+
+// resolveNames maps env and service names or aliases to their names in the
+// catalog. It answers with suggestions and returns false when one is unknown.
+func (h *Handler) resolveNames(w http.ResponseWriter, env string, services []string) (string, []string, bool) {
+	env, resolved, err := h.lookupNames(env, services)
+	if err != nil {
+		respondError(w, err, "look up names")
+		return "", nil, false
+	}
+	return env, resolved, true
+}
+
+// lookupNames is resolveNames for callers that report errors themselves.
+// An unknown name is a user-facing error with suggestions.
+func (h *Handler) lookupNames(env string, services []string) (string, []string, error) {
+	env, err := h.rules.ResolveEnv(env)
+	if err != nil {
+		return "", nil, userError("Validation error: %v\nSee what can be booked with `/slot envs`", err)
+	}
+
+	resolved := make([]string, 0, len(services))
+	for _, svc := range services {
+		name, err := h.rules.ResolveService(env, svc)
+		if err != nil {
+			return "", nil, userError("Validation error: %v\nSee what can be booked with `/slot envs %s`", err, env)
+		}
+		if !slices.Contains(resolved, name) {
+			resolved = append(resolved, name)
+		}
+	}
+	return env, resolved, nil
+}
+
+func (h *Handler) handleEnvsSubcommand(w http.ResponseWriter, r *http.Request, args []string) {
+	loc := h.userLocation(r)
+	if len(args) > 1 {
+		respond(w, "Usage: `/slot envs [env]`")
+		return
+	}
+
+	envs := h.rules.Catalog()
+	if len(args) == 1 {
+		name, _, ok := h.resolveNames(w, args[0], nil)
+		if !ok {
+			return
+		}
+		envs = slices.DeleteFunc(envs, func(e calendar.EnvCatalog) bool { return e.Name != name })
+	}
+
+	if h.store == nil {
+		respond(w, "❌ Calendar not configured. Please set up Google Calendar credentials (see SERVICE_ACCOUNT_SETUP.md)")
+		return
+	}
+
+	now := time.Now()
+	events, err := h.store.ListEvents(r.Context(), now.Add(-24*time.Hour), now.Add(time.Minute))
+	if err != nil {
+		slog.Error("Failed to list calendar events", "error", err)
+		respond(w, "❌ Failed to check calendar")
+		return
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "🗂️ *Environments*, times in %s:", loc)
+	for _, env := range envs {
+		// Holders right now, blackouts first as they lock every service
+		active := h.rules.BlackoutEvents(env.Name, now, now.Add(time.Nanosecond))
+		for _, e := range events {
+			if strings.EqualFold(e.Env, env.Name) && !e.StartTime.After(now) && e.EndTime.After(now) {
+				active = append(active, e)
+			}
+		}
+
+		fmt.Fprintf(&sb, "\n\n*%s*%s", env.Name, formatCatalogEntry(env.CatalogEntry))
+
+		if len(env.Services) == 0 {
+			sb.WriteString("\n• Any service can be booked")
+			for _, e := range active {
+				fmt.Fprintf(&sb, "\n• `%s` %s", displayService(e.Service), formatHolder(e, loc))
+			}
+			continue
+		}
+		for _, svc := range env.Services {
+			status := "🟢 free"
+			for _, e := range active {
+				if e.Service == domain.AllServices || strings.EqualFold(e.Service, svc.Name) {
+					status = formatHolder(e, loc)
+					break
+				}
+			}
+			fmt.Fprintf(&sb, "\n• `%s`%s %s", svc.Name, formatCatalogEntry(svc), status)
+		}
+	}
+	respond(w, sb.String())
+}
+
+// formatCatalogEntry describes an entry, e.g. " - Public API · <url|link> · team backend · aka apis"
+func formatCatalogEntry(e calendar.CatalogEntry) string {
+	var parts []string
+	if e.Description != "" {
+		parts = append(parts, e.Description)
+	}
+	if e.URL != "" {
+		parts = append(parts, fmt.Sprintf("<%s|link>", e.URL))
+	}
+	if e.Team != "" {
+		parts = append(parts, "team "+e.Team)
+	}
+	if len(e.Aliases) > 0 {
+		parts = append(parts, "aka "+strings.Join(e.Aliases, ", "))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " - " + strings.Join(parts, " · ")
+}
+
+// formatHolder says who holds a booking until when
+func formatHolder(e domain.Event, loc *time.Location) string {
+	until := e.EndTime.In(loc).Format("15:04")
+	if time.Until(e.EndTime) > 24*time.Hour {
+		until = e.EndTime.In(loc).Format("Mon 02 Jan 15:04")
+	}
+
+	if e.Blackout {
+		return fmt.Sprintf("%s, until %s", e.Title, until)
+	}
+	holder := e.User
+	if e.UserID != "" {
+		holder = "<@" + e.UserID + ">"
+	}
+	if holder == "" {
+		holder = e.Title
+	}
+	text := fmt.Sprintf("🔴 held by %s until %s", holder, until)
+	if e.Service == domain.AllServices {
+		text = fmt.Sprintf("🔒 locked by %s until %s", holder, until)
+	}
+	if e.JiraTicket != "" {
+		text += " (" + e.JiraTicket + ")"
+	}
+	return text
+}
@@ -0,0 +1,132 @@
+package slack
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yossigruner/SlotBot/internal/calendar"
+	"github.com/yossigruner/SlotBot/internal/domain"
+)
+
+func TestHandleEnvs(t *testing.T) {
+	policy, err := calendar.ParsePolicy([]byte(`
+envs:
+  staging:
+    description: Pre-production
+    aliases: [stg]
+    services:
+      api:
+        description: Public API
+        url: https://api.staging.example.com
+        team: backend
+        aliases: [api-server]
+      web: {}
+  qa: {}
+`))
+	if err != nil {
+		t.Fatalf("ParsePolicy() error = %v", err)
+	}
+	store := calendar.NewMemoryStore()
+	h := newTestHandlerWithRules(store, calendar.NewRules(policy, nil, nil))
+
+	// Held since before now, so it is active whatever the time
+	_, err = store.CreateEvent(context.Background(), domain.Booking{
+		Env:        "staging",
+		Service:    "api",
+		JiraTicket: "PROJ-1",
+		StartTime:  time.Now().Add(-30 * time.Minute),
+		Duration:   time.Hour,
+		User:       "alice",
+		UserID:     "U123",
+	})
+	if err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+
+	if got := slotCommand(t, h, "book stg API-Server PROJ-2 tomorrow 10:00"); !strings.Contains(got, "Booked!") {
+		t.Fatalf("book by aliases = %q, want booked", got)
+	}
+	tomorrow := time.Now().AddDate(0, 0, 1)
+	events, err := store.ListEvents(context.Background(), tomorrow.Add(-24*time.Hour), tomorrow.Add(24*time.Hour))
+	if err != nil || !slices.ContainsFunc(events, func(e domain.Event) bool { return e.JiraTicket == "PROJ-2" && e.Env == "staging" && e.Service == "api" }) {
+		t.Fatalf("stored events = %v, %v, want PROJ-2 for staging / api", events, err)
+	}
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"Lists services", "envs", "*staging* - Pre-production · aka stg"},
+		{"Describes services", "envs", "• `api` - Public API · <https://api.staging.example.com|link> · team backend · aka api-server 🔴 held by <@U123> until"},
+		{"Free service", "envs", "• `web` 🟢 free"},
+		{"Env without services", "envs", "*qa*\n• Any service can be booked"},
+		{"By alias", "envs stg", "*staging*"},
+		{"Unknown env", "envs stagin", "Did you mean staging?"},
+		{"Unknown service", "book staging apis PROJ-2", "staging has no service apis. Did you mean api?"},
+		{"Next by alias", "next stg api-server", "Next available slots for staging / api"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := slotCommand(t, h, tt.text); !strings.Contains(got, tt.want) {
+				t.Errorf("HandleUnified(%q) = %q, want it to contain %q", tt.text, got, tt.want)
+			}
+		})
+	}
+
+	if got := slotCommand(t, h, "envs qa"); strings.Contains(got, "staging") {
+		t.Errorf("envs qa = %q, want only qa", got)
+	}
+}
+
+func TestBookingCommandsResolveAliases(t *testing.T) {
+	policy, err := calendar.ParsePolicy([]byte(`
+envs:
+  staging:
+    aliases: [stg]
+    services:
+      api:
+        aliases: [apis]
+      web: {}
+`))
+	if err != nil {
+		t.Fatalf("ParsePolicy() error = %v", err)
+	}
+	store := calendar.NewMemoryStore()
+	h := newTestHandlerWithRules(store, calendar.NewRules(policy, nil, nil))
+
+	// bob holds staging/api, alice holds staging/web
+	now := time.Now()
+	for _, b := range []domain.Booking{
+		{Service: "api", User: "bob", UserID: "U999"},
+		{Service: "web", User: "alice", UserID: "U123"},
+	} {
+		b.Env, b.JiraTicket, b.StartTime, b.Duration = "staging", "PROJ-1", now.Add(-30*time.Minute), time.Hour
+		if _, err := store.CreateEvent(context.Background(), b); err != nil {
+			t.Fatalf("CreateEvent() error = %v", err)
+		}
+	}
+
+	if got := slotCommand(t, h, "queue stg apis PROJ-2 30m"); !strings.Contains(got, "`/slot unqueue staging api`") {
+		t.Errorf("queue = %q, want to be told to unqueue by the canonical names", got)
+	}
+	if got := slotCommand(t, h, "unqueue stg apis"); !strings.Contains(got, "You left the waitlist for staging / api") {
+		t.Errorf("unqueue by alias = %q, want to leave the line", got)
+	}
+
+	if got := slotCommandAs(t, h, "U999", "bob", "done stg apis"); !strings.Contains(got, "Released!") {
+		t.Errorf("done by alias = %q, want the booking released", got)
+	}
+
+	slotCommand(t, h, "book staging api PROJ-3 tomorrow 10:00")
+	if got := slotCommand(t, h, "cancel STG apis"); !strings.Contains(got, "Cancelled!") || !strings.Contains(got, "PROJ-3") {
+		t.Errorf("cancel by alias = %q, want the api booking cancelled", got)
+	}
+	if got := slotCommand(t, h, "cancel stg apy"); !strings.Contains(got, "Did you mean api") {
+		t.Errorf("cancel with a typo = %q, want a suggestion", got)
+	}
+}
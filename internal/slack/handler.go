@@ -1,31 +1,80 @@
 package slack
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/yossigruner/SlotBot/internal/calendar"
+	"github.com/yossigruner/SlotBot/internal/config"
 	"github.com/yossigruner/SlotBot/internal/domain"
+	"github.com/yossigruner/SlotBot/internal/grid"
+	"github.com/yossigruner/SlotBot/internal/timeexpr"
+	"github.com/yossigruner/SlotBot/internal/waitlist"
 )
 
 var jiraRegex = regexp.MustCompile(`^[A-Za-z]+-\d+$`)
 
 type Handler struct {
-	calClient  *calendar.Client
-	CalendarID string
+	store       calendar.BookingStore
+	rules       *calendar.Rules
+	waitlist    *waitlist.Dispatcher
+	CalendarID  string
+	mineHorizon time.Duration
+	bookASAP    bool
+	timezones   *Timezones
+	teams       calendar.TeamSource
+	approvals   *Approvals
 }
 
-func NewHandler(calClient *calendar.Client, calendarID string) *Handler {
+func NewHandler(store calendar.BookingStore, rules *calendar.Rules, dispatcher *waitlist.Dispatcher, timezones *Timezones, teams calendar.TeamSource, approvals *Approvals, cfg *config.Config) *Handler {
 	return &Handler{
-		calClient:  calClient,
-		CalendarID: calendarID,
+		store:       store,
+		rules:       rules,
+		waitlist:    dispatcher,
+		timezones:   timezones,
+		teams:       teams,
+		approvals:   approvals,
+		CalendarID:  cfg.GoogleCalendarID,
+		mineHorizon: cfg.MineHorizon,
+		bookASAP:    cfg.BookASAP,
 	}
 }
 
+// userLocation returns the timezone of the user who sent the command, which
+// their times are read and shown in
+func (h *Handler) userLocation(r *http.Request) *time.Location {
+	return h.timezones.Location(r.Context(), r.FormValue("user_id"))
+}
+
+// homeTimes shows the events in their env's home timezone, for envs whose
+// timezone differs from loc, e.g. "🕒 staging time (Europe/Berlin): Tue 03 Nov 20:00 - 21:00"
+func (h *Handler) homeTimes(events []domain.Event, loc *time.Location) string {
+	var sb strings.Builder
+	seen := make(map[string]bool)
+	for _, e := range events {
+		home := h.rules.ScheduleFor(e.Env).Location
+		if home == nil || home.String() == loc.String() {
+			continue
+		}
+
+		line := fmt.Sprintf("\n🕒 %s time (%s): %s - %s", e.Env, home,
+			e.StartTime.In(home).Format("Mon 02 Jan 15:04"), e.EndTime.In(home).Format("15:04"))
+		if !seen[line] {
+			seen[line] = true
+			sb.WriteString(line)
+		}
+	}
+	return sb.String()
+}
+
 // HandleUnified is the main handler that routes to subcommands
 func (h *Handler) HandleUnified(w http.ResponseWriter, r *http.Request) {
 	if err := r.ParseForm(); err != nil {
@@ -34,7 +83,7 @@ func (h *Handler) HandleUnified(w http.ResponseWriter, r *http.Request) {
 	}
 
 	text := r.FormValue("text")
-	args := strings.Fields(text)
+	args := splitArgs(text)
 
 	if len(args) == 0 {
 		helpText := `📚 *SlotBot - Environment Booking Manager*
@@ -45,33 +94,63 @@ func (h *Handler) HandleUnified(w http.ResponseWriter, r *http.Request) {
 ` + "`/slot book <env> <service> <jira> [start] [duration]`" + `
 Book a testing environment for your team.
 • *env*: Environment name (e.g., staging, qa, demo)
-• *service*: Service name (e.g., api, web, db)
+• *service*: Service name (e.g., api, web, db), or several like api,web,worker to book them all or none. Use *all* to lock the whole environment
 • *jira*: Jira ticket (e.g., PROJ-123 or og-1234)
-• *start*: (Optional) Start time (ISO, HH:MM, or relative like '2h')
-• *duration*: (Optional) Duration (default: 1h)
+• *start*: (Optional) Start time: HH:MM, tomorrow 14:00, mon 9:30, 2026-11-03 10:00, in 45m, or a range like 14:00-15:30, until 17:00 or eod
+• *duration*: (Optional) Duration (default: 1h, or up to the end time)
+• *--every*: (Optional) Repeat daily, weekdays or weekly, with *--until* <YYYY-MM-DD>
+• *--asap*: (Optional) If the time is taken, book the next available slot instead (*--no-asap* if that's the default)
+• *--urgent "reason"*: (Optional) If the time is taken, ask an approver to cut short or move the bookings in the way
 
 *Examples:*
 ` + "`/slot book staging api OG-1234`" + `
 ` + "`/slot book qa web OG-456 14:30`" + ` (Start at 14:30 today)
-` + "`/slot book demo db OG-789 2h`" + ` (Start in 2 hours)
+` + "`/slot book demo db OG-789 in 2h`" + ` (Start in 2 hours)
+` + "`/slot book qa web OG-457 tomorrow 9:30 30m`" + ` (Tomorrow at 9:30 for 30 minutes)
+` + "`/slot book staging api OG-458 until 17:00`" + ` (From now until 17:00)
+` + "`/slot book staging api,web,worker OG-1 14:00`" + ` (All three together)
+` + "`/slot book qa all OG-900 22:00 2h`" + ` (Lock all of qa, e.g. for a DB migration)
+` + "`/slot book staging api OG-55 14:00 --asap`" + ` (14:00, or the first free slot after it's taken)
+` + "`/slot book qa api OG-321 02:00 1h --every weekdays --until 2026-12-31`" + ` (Nightly regression)
+` + "`/slot book staging api OG-911 14:00 --urgent \"verify prod hotfix\"`" + ` (Bump whoever has it, once approved)
 
 *2️⃣ Find Next Available Slot*
-` + "`/slot next <env> <service> [duration]`" + `
-Find the next available time slot for an environment.
+` + "`/slot next <env> <service[,service...]> [duration] [--count <n>] [--after <time>]`" + `
+List the next free gaps (3 by default) where all listed services are free for the duration, with how long each gap lasts.
 
 *Examples:*
 ` + "`/slot next staging api`" + `
 ` + "`/slot next qa web 2h`" + `
+` + "`/slot next staging api,web 30m --count 5 --after tomorrow`" + `
 
-*3️⃣ List Today's Bookings*
-` + "`/slot list [env]`" + `
-View all bookings for today, optionally filtered by environment.
+*3️⃣ List Bookings*
+` + "`/slot list [env] [when] [service=<name>] [user=<name>]`" + `
+View bookings grouped by day. *when* is today (default), tomorrow, yesterday, week, a weekday, an ISO date, or a range like 2026-11-02..2026-11-06.
 
 *Examples:*
 ` + "`/slot list`" + `
 ` + "`/slot list staging`" + `
+` + "`/slot list qa tomorrow`" + `
+` + "`/slot list week user=@alice`" + `
+` + "`/slot list staging today..fri service=api`" + `
+
+*4️⃣ Availability Grid*
+` + "`/slot grid <env> [date]`" + `
+Draw a day as a timeline, one row per service and one column per 15 minutes, to spot the gaps.
+
+*Examples:*
+` + "`/slot grid staging`" + `
+` + "`/slot grid qa tomorrow`" + `
 
-*4️⃣ Show Active Bookings*
+*5️⃣ Join the Waitlist*
+` + "`/slot queue <env> <service> <jira> [duration]`" + `
+Busy? Wait in line - when it frees up, it's booked for you automatically and you get a DM.
+` + "`/slot queue`" + ` shows your positions, ` + "`/slot unqueue <env> <service>`" + ` leaves the line.
+
+*Examples:*
+` + "`/slot queue staging api OG-1234 30m`" + `
+
+*6️⃣ Show Active Bookings*
 ` + "`/slot current [env]`" + ` (or ` + "`/slot now`" + `)
 View what is currently booked right now.
 
@@ -79,14 +158,61 @@ View what is currently booked right now.
 ` + "`/slot current`" + `
 ` + "`/slot now staging`" + `
 
-*5️⃣ Open Calendar*
+*7️⃣ List My Bookings*
+` + "`/slot mine`" + `
+View all your upcoming bookings with their booking IDs.
+
+*8️⃣ Cancel a Booking*
+` + "`/slot cancel [booking-id | env service]`" + `
+Cancel your own current or upcoming booking. The ID you got for a recurring booking cancels the whole series; with the other commands it stands for its current or next occurrence.
+
+*Examples:*
+` + "`/slot cancel`" + ` (Your only upcoming booking)
+` + "`/slot cancel staging api`" + `
+
+*9️⃣ Extend a Booking*
+` + "`/slot extend [booking-id | env service] <duration>`" + `
+Add time to the booking you hold right now, if nobody has booked right after it.
+
+*Examples:*
+` + "`/slot extend 30m`" + `
+` + "`/slot extend staging api 1h`" + `
+
+*🔟 Move or Edit a Booking*
+` + "`/slot move <booking-id> <start> [duration]`" + `
+` + "`/slot edit <booking-id> jira=<ticket>`" + `
+Reschedule your booking or change its Jira ticket without losing your place.
+
+*Examples:*
+` + "`/slot move 4k2v9q0abc 15:30`" + `
+` + "`/slot move 4k2v9q0abc in 1h 30m`" + ` (Start in 1 hour, for 30 minutes)
+` + "`/slot move 4k2v9q0abc tomorrow 10:00-11:30`" + `
+` + "`/slot edit 4k2v9q0abc jira=OG-42`" + `
+
+*1️⃣1️⃣ Release a Booking Early*
+` + "`/slot done [booking-id | env service]`" + `
+Finished early? End your active booking now so others can use the environment.
+
+*Examples:*
+` + "`/slot done`" + `
+` + "`/slot done staging api`" + `
+
+*1️⃣2️⃣ Open Calendar*
 ` + "`/slot open`" + `
 Open Google Calendar in your browser.
 
-*6️⃣ Add Calendar*
+*1️⃣3️⃣ Add Calendar*
 ` + "`/slot add`" + `
 Add the SlotBot calendar to your Google Calendar list.
 
+*1️⃣4️⃣ Check Your Quota*
+` + "`/slot quota [@user]`" + `
+See how many hours you (or a teammate) have booked today and this week against the quotas, and how many bookings you hold at once.
+
+*1️⃣5️⃣ Browse Environments*
+` + "`/slot envs [env]`" + `
+List the environments and their services with what they are, who owns them and the other names they go by, and who holds each one right now.
+
 💡 *Tip:* All bookings are automatically rounded to 15-minute intervals (:00, :15, :30, :45)`
 		respond(w, helpText)
 		return
@@ -102,17 +228,80 @@ Add the SlotBot calendar to your Google Calendar list.
 		h.handleNextSubcommand(w, r, remainingArgs)
 	case "list":
 		h.handleListSubcommand(w, r, remainingArgs)
+	case "grid":
+		h.handleGridSubcommand(w, r, remainingArgs)
 	case "current", "now":
 		h.handleCurrentSubcommand(w, r, remainingArgs)
+	case "queue":
+		h.handleQueueSubcommand(w, r, remainingArgs)
+	case "unqueue":
+		h.handleUnqueueSubcommand(w, r, remainingArgs)
+	case "mine":
+		h.handleMineSubcommand(w, r, remainingArgs)
+	case "quota":
+		h.handleQuotaSubcommand(w, r, remainingArgs)
+	case "envs":
+		h.handleEnvsSubcommand(w, r, remainingArgs)
+	case "cancel":
+		h.handleCancelSubcommand(w, r, remainingArgs)
+	case "extend":
+		h.handleExtendSubcommand(w, r, remainingArgs)
+	case "move":
+		h.handleMoveSubcommand(w, r, remainingArgs)
+	case "edit":
+		h.handleEditSubcommand(w, r, remainingArgs)
+	case "done":
+		h.handleDoneSubcommand(w, r, remainingArgs)
 	case "open":
 		h.handleOpenSubcommand(w, r, remainingArgs)
 	case "add":
 		h.handleAddSubcommand(w, r, remainingArgs)
 	default:
-		respond(w, fmt.Sprintf("❌ Unknown subcommand: %s\n\nAvailable commands:\n• `book` - Book an environment\n• `next` - Find next available slot\n• `list` - List today's bookings\n• `current` - Show active bookings\n• `open` - Open calendar\n• `add` - Add calendar to your list", subcommand))
+		respond(w, fmt.Sprintf("❌ Unknown subcommand: %s\n\nAvailable commands:\n• `book` - Book an environment\n• `next` - Find next available slot\n• `list` - List bookings for a day or range\n• `grid` - Show an environment's day as a timeline\n• `queue` - Join the waitlist for a busy environment\n• `unqueue` - Leave the waitlist\n• `current` - Show active bookings\n• `mine` - List your upcoming bookings\n• `quota` - Show how much of your booking quota is used\n• `envs` - List environments, services and their holders\n• `cancel` - Cancel a booking\n• `extend` - Extend an active booking\n• `move` - Reschedule a booking\n• `edit` - Change a booking's Jira ticket\n• `done` - Release a booking early\n• `open` - Open calendar\n• `add` - Add calendar to your list", subcommand))
 	}
 }
 
+func (h *Handler) handleGridSubcommand(w http.ResponseWriter, r *http.Request, args []string) {
+	loc := h.userLocation(r)
+	if len(args) < 1 || len(args) > 2 {
+		respond(w, "Usage: `/slot grid <env> [date]`")
+		return
+	}
+
+	env, _, ok := h.resolveNames(w, args[0], nil)
+	if !ok {
+		return
+	}
+	dayArg := ""
+	if len(args) > 1 {
+		dayArg = args[1]
+	}
+
+	days, err := timeexpr.ParseDays(dayArg, time.Now().In(loc))
+	if err != nil {
+		respond(w, fmt.Sprintf("❌ %v", err))
+		return
+	}
+	if !days.End.Equal(days.Start.AddDate(0, 0, 1)) {
+		respond(w, "❌ The grid shows a single day. Use `/slot list` for a range")
+		return
+	}
+
+	if h.store == nil {
+		respond(w, "❌ Calendar not configured. Please set up Google Calendar credentials (see SERVICE_ACCOUNT_SETUP.md)")
+		return
+	}
+
+	events, err := h.store.ListEvents(r.Context(), days.Start, days.End)
+	if err != nil {
+		slog.Error("Failed to list calendar events", "error", err)
+		respond(w, "❌ Failed to check calendar")
+		return
+	}
+
+	respond(w, "```\n"+grid.New(env, days.Start, events).String()+"```")
+}
+
 func (h *Handler) handleOpenSubcommand(w http.ResponseWriter, r *http.Request, args []string) {
 	// Generic link to open Google Calendar
 	respond(w, "📅 *Open Google Calendar*\n<https://calendar.google.com/calendar/r|Click here to view your calendar>")
@@ -133,12 +322,17 @@ func (h *Handler) handleAddSubcommand(w http.ResponseWriter, r *http.Request, ar
 }
 
 func (h *Handler) handleCurrentSubcommand(w http.ResponseWriter, r *http.Request, args []string) {
+	loc := h.userLocation(r)
 	envFilter := ""
 	if len(args) > 0 {
-		envFilter = strings.ToLower(args[0])
+		env, _, ok := h.resolveNames(w, args[0], nil)
+		if !ok {
+			return
+		}
+		envFilter = env
 	}
 
-	if h.calClient == nil {
+	if h.store == nil {
 		respond(w, "❌ Calendar not configured. Please set up Google Calendar credentials (see SERVICE_ACCOUNT_SETUP.md)")
 		return
 	}
@@ -148,11 +342,11 @@ func (h *Handler) handleCurrentSubcommand(w http.ResponseWriter, r *http.Request
 	// We want events where Start <= Now < End.
 	// So we need events that started before now and end after now.
 	// Safest is to fetch today's events and filter in memory.
-	now := time.Now()
+	now := time.Now().In(loc)
 	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
 	endOfDay := startOfDay.Add(24 * time.Hour)
 
-	events, err := h.calClient.ListEvents(r.Context(), startOfDay, endOfDay)
+	events, err := h.store.ListEvents(r.Context(), startOfDay, endOfDay)
 	if err != nil {
 		slog.Error("Failed to list calendar events", "error", err)
 		respond(w, "❌ Failed to check calendar")
@@ -182,19 +376,27 @@ func (h *Handler) handleCurrentSubcommand(w http.ResponseWriter, r *http.Request
 	}
 
 	var response strings.Builder
-	response.WriteString(fmt.Sprintf("🔴 Currently Active Bookings (%d):\n\n", len(activeEvents)))
+	response.WriteString(fmt.Sprintf("🔴 Currently Active Bookings (%d), times in %s:\n\n", len(activeEvents), loc))
 	response.WriteString("```\n")
-	response.WriteString(formatEventsTable(activeEvents))
+	response.WriteString(formatEventsTable(activeEvents, loc))
 	response.WriteString("```")
 
 	respond(w, response.String())
 }
 
 func (h *Handler) handleBookSubcommand(w http.ResponseWriter, r *http.Request, args []string) {
-	userID := r.FormValue("user_name")
+	loc := h.userLocation(r)
+	userName := r.FormValue("user_name")
+	userID := r.FormValue("user_id")
+
+	args, flags, err := splitFlags(args, map[string]bool{"every": true, "until": true, "asap": false, "no-asap": false, "urgent": true})
+	if err != nil {
+		respond(w, fmt.Sprintf("❌ %v", err))
+		return
+	}
 
 	if len(args) < 3 {
-		respond(w, "Usage: `/slot book <env> <service> <jira> [start] [duration]`")
+		respond(w, "Usage: `/slot book <env> <service> <jira> [start] [duration] [--asap] [--every daily|weekdays|weekly --until <YYYY-MM-DD>] [--urgent \"<reason>\"]`")
 		return
 	}
 
@@ -208,146 +410,264 @@ func (h *Handler) handleBookSubcommand(w http.ResponseWriter, r *http.Request, a
 	}
 
 	// Default start: now, duration: 1h
-	startTime := time.Now()
-	duration := time.Hour
-
-	if len(args) > 3 {
-		// Try parsing as ISO time
-		parsedStart, err := time.Parse(time.RFC3339, args[3])
-		if err == nil {
-			startTime = parsedStart
-		} else {
-			// Try parsing as HH:MM (Kitchen/24h format)
-			// We assume today if only time is given
-			parsedTime, timeErr := time.Parse("15:04", args[3])
-			if timeErr == nil {
-				now := time.Now()
-				startTime = time.Date(now.Year(), now.Month(), now.Day(), parsedTime.Hour(), parsedTime.Minute(), 0, 0, now.Location())
-				// If time has passed today, maybe they mean tomorrow?
-				// For now, let's keep it simple: today.
-			} else {
-				// Try parsing as duration (relative time)
-				d, dErr := time.ParseDuration(args[3])
-				if dErr == nil {
-					// args[3] is start offset (e.g. "2h" -> in 2 hours)
-					startTime = startTime.Add(d)
-				}
-			}
-		}
-
-		// If we successfully parsed a start time (ISO or HH:MM or relative), check for duration in next arg
-		if len(args) > 4 {
-			d, err := time.ParseDuration(args[4])
-			if err == nil {
-				duration = d
-			}
-		} else if len(args) == 4 {
-			// If args[3] was a duration (e.g., "/slot book env svc jira 2h"), then it's the duration
-			// This case is only hit if args[3] was NOT an ISO time or HH:MM.
-			// The `startTime = startTime.Add(d)` above handles the "start in X duration" case.
-			// If args[3] was just a duration and no start time was specified, then startTime remains time.Now()
-			// and args[3] is the duration.
-			_, isoErr := time.Parse(time.RFC3339, args[3])
-			_, hhmmErr := time.Parse("15:04", args[3])
-			if isoErr != nil && hhmmErr != nil { // Only if args[3] was not ISO or HH:MM
-				d, dErr := time.ParseDuration(args[3])
-				if dErr == nil {
-					duration = d
-				}
-			}
-		}
+	startTime, duration, err := parseWhen(args[3:], time.Now().In(loc))
+	if err != nil {
+		respond(w, fmt.Sprintf("❌ Invalid start time: %v", err))
+		return
+	}
+	if duration == 0 {
+		duration = time.Hour
 	}
 
 	// Round start time to nearest 15-minute interval
-	startTime = roundToQuarterHour(startTime)
+	startTime = grid.Round(startTime)
+
+	// Several comma-separated services are booked together, all or nothing
+	services := splitServices(service)
+	if len(services) == 0 {
+		respond(w, "❌ Please specify at least one service")
+		return
+	}
+	env, services, ok := h.resolveNames(w, env, services)
+	if !ok {
+		return
+	}
+	service = strings.Join(services, ",")
 
 	booking := domain.Booking{
 		Env:        env,
-		Service:    service,
+		Service:    services[0],
 		JiraTicket: jira,
 		StartTime:  startTime,
 		Duration:   duration,
-		User:       userID,
+		User:       userName,
+		UserID:     userID,
+	}
+
+	if every, ok := flags["every"]; ok {
+		until, ok := flags["until"]
+		if !ok {
+			respond(w, "❌ Recurring bookings need an end date: `--until <YYYY-MM-DD>`")
+			return
+		}
+		untilDate, err := time.ParseInLocation(time.DateOnly, until, startTime.Location())
+		if err != nil {
+			respond(w, fmt.Sprintf("❌ Invalid end date: %s. Use YYYY-MM-DD", until))
+			return
+		}
+		booking.Recurrence = &domain.Recurrence{
+			Frequency: strings.ToLower(every),
+			Until:     untilDate,
+		}
+	} else if _, ok := flags["until"]; ok {
+		respond(w, "❌ `--until` needs `--every daily|weekdays|weekly`")
+		return
 	}
 
-	if err := calendar.ValidateBooking(booking); err != nil {
-		respond(w, fmt.Sprintf("❌ Validation error: %v", err))
+	urgent, isUrgent := flags["urgent"]
+	if isUrgent && strings.TrimSpace(urgent) == "" {
+		respond(w, "❌ `--urgent` needs a reason, e.g. `--urgent \"hotfix for OG-1\"`")
 		return
 	}
+	if isUrgent && booking.Recurrence != nil {
+		respond(w, "❌ Recurring bookings can't be urgent")
+		return
+	}
+
+	bookings := make([]domain.Booking, len(services))
+	for i, svc := range services {
+		bookings[i] = booking
+		bookings[i].Service = svc
 
-	if h.calClient == nil {
+		if err := h.rules.ValidateBooking(bookings[i]); err != nil {
+			respond(w, fmt.Sprintf("❌ Validation error: %v", err))
+			return
+		}
+	}
+
+	if h.store == nil {
 		respond(w, "❌ Calendar not configured. Please set up Google Calendar credentials (see SERVICE_ACCOUNT_SETUP.md)")
 		return
 	}
 
-	events, err := h.calClient.ListEvents(r.Context(), startTime.Add(-24*time.Hour), startTime.Add(24*time.Hour))
+	occurrences := calendar.Occurrences(booking)
+	lastStart := occurrences[len(occurrences)-1]
+
+	events, err := h.store.ListEvents(r.Context(), startTime.Add(-24*time.Hour), lastStart.Add(24*time.Hour))
 	if err != nil {
 		respond(w, "❌ Failed to check calendar")
 		return
 	}
 
-	if conflict := calendar.CheckConflict(booking, events); conflict != nil {
-		slog.Info("Booking conflict detected", "env", booking.Env, "service", booking.Service, "conflict_with", conflict.Title)
+	if booking.Recurrence != nil {
+		var conflicting []domain.Event
+		for _, b := range bookings {
+			for _, c := range h.rules.CheckRecurringConflicts(b, events) {
+				conflicting = append(conflicting, c.With)
+			}
+		}
+
+		if len(conflicting) > 0 {
+			slog.Info("Recurring booking conflicts detected", "env", booking.Env, "services", services, "conflicts", len(conflicting))
+
+			respond(w, fmt.Sprintf("❌ %d of %d occurrences conflict with existing bookings:\n```\n%s```\nNothing was booked.",
+				len(conflicting), len(occurrences)*len(bookings), formatBookingsTable(conflicting, loc)))
+			return
+		}
+	}
 
-		// Find next available slot
-		// We need to fetch more events to find the next slot reliably
-		searchStart := time.Now()
-		extendedEvents, err := h.calClient.ListEvents(r.Context(), searchStart, searchStart.Add(7*24*time.Hour))
+	var conflicts []domain.Event
+	for _, b := range bookings {
+		if conflict := h.rules.CheckConflict(b, events); conflict != nil {
+			conflicts = append(conflicts, *conflict)
+		}
+	}
+
+	// An urgent booking asks to displace what's in its way instead
+	if isUrgent && len(conflicts) > 0 {
+		var all []domain.Event
+		for _, b := range bookings {
+			all = append(all, h.rules.Conflicts(b, events)...)
+		}
+		for _, e := range all {
+			if e.Blackout {
+				respond(w, fmt.Sprintf("❌ Not even urgent bookings can displace a blackout:\n```\n%s```", formatEventsTable([]domain.Event{e}, loc)))
+				return
+			}
+		}
+		if !h.checkQuota(w, r, bookings...) {
+			return
+		}
+		h.requestOverride(w, r, bookings, all, urgent)
+		return
+	}
+
+	// With --asap a conflicting booking moves to the next free slot instead
+	asap := h.bookASAP
+	if _, ok := flags["asap"]; ok {
+		asap = true
+	}
+	if _, ok := flags["no-asap"]; ok {
+		asap = false
+	}
+
+	var movedFrom time.Time
+	if len(conflicts) > 0 {
+		slog.Info("Booking conflict detected", "env", booking.Env, "services", services, "conflict_with", conflicts[0].Title)
+
+		// Find the next slot from the requested start, or from now if that
+		// has passed. We need to fetch more events to find it reliably.
+		now := time.Now()
+		searchStart := now
+		if booking.StartTime.After(now) {
+			searchStart = booking.StartTime
+		}
+		searchEnd := now.Add(h.searchWindow(booking.Env))
+		extendedEvents, err := h.store.ListEvents(r.Context(), searchStart, searchEnd)
 		if err != nil {
 			slog.Error("Failed to list events for next slot search", "error", err)
 			// Fallback to simple conflict message if we can't search
-			respond(w, fmt.Sprintf("❌ Conflict detected!\n```\n%s```", formatEventsTable([]domain.Event{*conflict})))
+			respond(w, fmt.Sprintf("❌ Conflict detected!\n```\n%s```", formatEventsTable(conflicts, loc)))
 			return
 		}
 
-		nextSlot := calendar.FindNextSlot(booking.Env, booking.Service, booking.Duration, extendedEvents)
+		var nextSlot time.Time
+		slots, err := h.rules.FreeSlots(booking.Env, services, booking.Duration, extendedEvents, searchStart, 1)
+		if err == nil {
+			nextSlot = slots[0].Start
+			if nextSlot.Add(booking.Duration).After(searchEnd) {
+				err = calendar.ErrNoSlot
+			}
+		}
+		if err != nil {
+			respond(w, fmt.Sprintf("❌ Conflict detected, and there is no free slot in the next %s:\n```\n%s```",
+				formatWindow(h.searchWindow(booking.Env)), formatEventsTable(conflicts, loc)))
+			return
+		}
 
-		// Format the next slot suggestion
-		suggestion := fmt.Sprintf("/slot book %s %s %s %s %s",
-			booking.Env,
-			booking.Service,
-			booking.JiraTicket,
-			nextSlot.Format(time.RFC3339),
-			booking.Duration)
+		if asap && booking.Recurrence == nil {
+			movedFrom = booking.StartTime
+			bookings = h.moveBookings(bookings, nextSlot, extendedEvents)
+			slog.Info("Moved conflicting booking to next slot", "env", booking.Env, "services", services, "start", bookings[0].StartTime)
+		} else {
+			// Format the next slot suggestion
+			suggestion := fmt.Sprintf("/slot book %s %s %s %s %s",
+				booking.Env,
+				strings.Join(services, ","),
+				booking.JiraTicket,
+				nextSlot.Format(time.RFC3339),
+				booking.Duration)
+
+			message := fmt.Sprintf("❌ Conflict detected!\n```\n%s```\n👉 *Next available slot:*\n%s\nTo book it, copy and paste:\n`%s`\nor add `--asap` to take it automatically.",
+				formatEventsTable(conflicts, loc),
+				nextSlot.In(loc).Format("Mon, 02 Jan 15:04"),
+				suggestion)
+			if h.waitlist != nil && len(services) == 1 {
+				message += fmt.Sprintf("\nOr wait in line and get it as soon as it frees up:\n`/slot queue %s %s %s %s`",
+					booking.Env, service, booking.JiraTicket, booking.Duration)
+			}
+
+			respond(w, message)
+			return
+		}
+	}
 
-		respond(w, fmt.Sprintf("❌ Conflict detected!\n```\n%s```\n👉 *Next available slot:*\n%s\nTo book it, copy and paste:\n`%s`",
-			formatEventsTable([]domain.Event{*conflict}),
-			nextSlot.Format("Mon, 02 Jan 15:04"),
-			suggestion))
+	if !h.checkQuota(w, r, bookings...) {
 		return
 	}
 
-	link, err := h.calClient.CreateEvent(r.Context(), booking)
+	newEvents, err := calendar.CreateBookings(r.Context(), h.store, bookings)
 	if err != nil {
 		slog.Error("Failed to create calendar event", "error", err)
-		respond(w, "❌ Failed to create calendar event")
+		if len(bookings) > 1 {
+			respond(w, "❌ Failed to create calendar events - nothing was booked")
+		} else {
+			respond(w, "❌ Failed to create calendar event")
+		}
 		return
 	}
 
-	slog.Info("Booking created", "env", booking.Env, "service", booking.Service, "user", booking.User)
+	slog.Info("Booking created", "env", booking.Env, "services", services, "user", booking.User)
 
-	// Create event object for display
-	newEvent := domain.Event{
-		Env:       booking.Env,
-		Service:   booking.Service,
-		Title:     fmt.Sprintf("%s | %s | %s | %s", booking.Env, booking.Service, booking.JiraTicket, booking.User),
-		StartTime: booking.StartTime,
-		EndTime:   booking.StartTime.Add(booking.Duration),
+	ids := make([]string, len(newEvents))
+	for i, e := range newEvents {
+		ids[i] = "`" + e.ID + "`"
+	}
+
+	message := fmt.Sprintf("✅ Booked!\n```\n%s```%s\nBooking ID: %s", formatEventsTable(newEvents, loc), h.homeTimes(newEvents, loc), strings.Join(ids, ", "))
+	if !movedFrom.IsZero() {
+		message = fmt.Sprintf("⏩ %s was taken, so you got the next available slot at %s.\n",
+			movedFrom.In(loc).Format("Mon, 02 Jan 15:04"), newEvents[0].StartTime.In(loc).Format("Mon, 02 Jan 15:04")) + message
+	}
+	if booking.Recurrence != nil {
+		message += fmt.Sprintf("\n🔁 Repeats %s until %s (%d occurrences)",
+			booking.Recurrence.Frequency, lastStart.In(loc).Format("Mon 02 Jan"), len(occurrences))
+	}
+	if len(newEvents) == 1 && newEvents[0].Link != "" {
+		message += fmt.Sprintf("\nLink: <%s|Open in Calendar>", newEvents[0].Link)
 	}
 
-	respond(w, fmt.Sprintf("✅ Booked!\n```\n%s```\nLink: <%s|Open in Calendar>",
-		formatEventsTable([]domain.Event{newEvent}),
-		link))
+	respond(w, message)
 }
 
 func (h *Handler) handleNextSubcommand(w http.ResponseWriter, r *http.Request, args []string) {
+	loc := h.userLocation(r)
+	args, flags, err := splitFlags(args, map[string]bool{"after": true, "count": true})
+	if err != nil {
+		respond(w, fmt.Sprintf("❌ %v", err))
+		return
+	}
+
 	if len(args) < 2 {
-		respond(w, "Usage: `/slot next <env> <service> [duration]`")
+		respond(w, "Usage: `/slot next <env> <service[,service...]> [duration] [--count <n>] [--after <time>]`")
 		return
 	}
 
-	env := args[0]
-	service := args[1]
+	env, services, ok := h.resolveNames(w, args[0], splitServices(args[1]))
+	if !ok {
+		return
+	}
+	service := strings.Join(services, ",")
 	duration := time.Hour
 
 	if len(args) > 2 {
@@ -357,78 +677,441 @@ func (h *Handler) handleNextSubcommand(w http.ResponseWriter, r *http.Request, a
 		}
 	}
 
-	if h.calClient == nil {
+	count := defaultSlotCount
+	if v, ok := flags["count"]; ok {
+		count, err = strconv.Atoi(v)
+		if err != nil || count < 1 || count > maxSlotCount {
+			respond(w, fmt.Sprintf("❌ Invalid --count: %s. Use a number from 1 to %d", v, maxSlotCount))
+			return
+		}
+	}
+
+	now := time.Now().In(loc)
+	from := now
+	if v, ok := flags["after"]; ok {
+		from, err = parseAfter(v, now)
+		if err != nil {
+			respond(w, fmt.Sprintf("❌ Invalid --after: %v", err))
+			return
+		}
+	}
+
+	if h.store == nil {
 		respond(w, "❌ Calendar not configured. Please set up Google Calendar credentials (see SERVICE_ACCOUNT_SETUP.md)")
 		return
 	}
 
-	now := time.Now()
-	events, err := h.calClient.ListEvents(r.Context(), now, now.Add(7*24*time.Hour))
+	searchEnd := now.Add(h.searchWindow(env))
+	events, err := h.store.ListEvents(r.Context(), from, searchEnd)
 	if err != nil {
 		respond(w, "❌ Failed to check calendar")
 		return
 	}
 
-	nextSlot := calendar.FindNextSlot(env, service, duration, events)
+	// Only what the listed events cover is known to be free
+	slots, err := h.rules.FreeSlots(env, services, duration, events, from, count)
+	for len(slots) > 0 && slots[len(slots)-1].Start.Add(duration).After(searchEnd) {
+		slots = slots[:len(slots)-1]
+	}
+	if err != nil || len(slots) == 0 {
+		respond(w, fmt.Sprintf("🔍 No free slot for %s / %s (%s) in the next %s. Open hours: %s",
+			env, service, duration, formatWindow(h.searchWindow(env)), calendar.FormatSchedule(h.rules.ScheduleFor(env))))
+		return
+	}
+
+	var response strings.Builder
+	response.WriteString(fmt.Sprintf("🔍 Next available slots for %s / %s (%s):\n", env, service, duration))
+	for _, slot := range slots {
+		response.WriteString("👉 " + formatSlot(slot, searchEnd, loc, h.rules.ScheduleFor(env).Location) + "\n")
+	}
+	response.WriteString(fmt.Sprintf("To book one: `/slot book %s %s <jira> <start> %s`", env, service, duration))
 
-	respond(w, fmt.Sprintf("🔍 Next available slot for %s / %s (%s):\n👉 %s",
-		env, service, duration, nextSlot.Format("Mon, 02 Jan 15:04")))
+	respond(w, response.String())
+}
+
+// Number of slots /slot next lists by default and at most
+const (
+	defaultSlotCount = 3
+	maxSlotCount     = 10
+)
+
+// parseAfter parses the --after time of /slot next: a start-time expression
+// like "14:00" or "tomorrow 9:00", or a day like "tomorrow" or "mon" for
+// that day's opening.
+func parseAfter(arg string, now time.Time) (time.Time, error) {
+	r, err := timeexpr.Parse(arg, now)
+	if err == nil {
+		return r.Start, nil
+	}
+	if errors.Is(err, timeexpr.ErrInPast) {
+		return time.Time{}, err
+	}
+
+	days, err := timeexpr.ParseDays(arg, now)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if days.Start.Before(now) {
+		return now, nil
+	}
+	return days.Start, nil
+}
+
+// formatSlot describes a free interval in loc, e.g. "Tue 03 Nov 09:00 - 11:30 (2h30m free)",
+// adding the start in the env's home timezone if that differs. Gaps running
+// past the searched window are open-ended.
+func formatSlot(slot calendar.Slot, searchEnd time.Time, loc, home *time.Location) string {
+	start := slot.Start.In(loc).Format("Mon 02 Jan 15:04")
+
+	var out string
+	if !slot.End.Before(searchEnd) {
+		out = fmt.Sprintf("%s onwards (free for %s+)", start, formatFree(searchEnd.Sub(slot.Start)))
+	} else {
+		end := slot.End.In(loc).Format("15:04")
+		if !sameDay(slot.Start.In(loc), slot.End.In(loc)) {
+			end = slot.End.In(loc).Format("Mon 02 Jan 15:04")
+		}
+		out = fmt.Sprintf("%s - %s (%s free)", start, end, formatFree(slot.End.Sub(slot.Start)))
+	}
+
+	if home != nil && home.String() != loc.String() {
+		out += fmt.Sprintf(" - %s %s", slot.Start.In(home).Format("15:04"), home)
+	}
+	return out
+}
+
+// formatFree renders a gap length rounded to the minute, e.g. "2h30m"
+func formatFree(d time.Duration) string {
+	d = d.Round(time.Minute)
+	hours, minutes := int(d.Hours()), int(d.Minutes())%60
+	switch {
+	case d >= 48*time.Hour:
+		return fmt.Sprintf("%d days", hours/24)
+	case hours == 0:
+		return fmt.Sprintf("%dm", minutes)
+	case minutes == 0:
+		return fmt.Sprintf("%dh", hours)
+	}
+	return fmt.Sprintf("%dh%02dm", hours, minutes)
+}
+
+func sameDay(a, b time.Time) bool {
+	return a.Year() == b.Year() && a.YearDay() == b.YearDay()
 }
 
 func (h *Handler) handleListSubcommand(w http.ResponseWriter, r *http.Request, args []string) {
-	envFilter := ""
-	if len(args) > 0 {
-		envFilter = strings.ToLower(args[0])
+	loc := h.userLocation(r)
+	now := time.Now().In(loc)
+	days, err := timeexpr.ParseDays("", now)
+	if err != nil {
+		respond(w, fmt.Sprintf("❌ %v", err))
+		return
+	}
+	daysLabel := "today"
+
+	// Anything that isn't a filter or a date is the env
+	var envFilter, serviceFilter, userFilter string
+	for _, arg := range args {
+		key, value, isFilter := strings.Cut(arg, "=")
+		switch {
+		case isFilter && strings.EqualFold(key, "service"):
+			serviceFilter = normalizeService(value)
+		case isFilter && strings.EqualFold(key, "user"):
+			userFilter = normalizeUser(value)
+		case isFilter:
+			respond(w, fmt.Sprintf("❌ Unknown filter: %s. Use `service=<name>` or `user=<name>`", key))
+			return
+		default:
+			if parsed, err := timeexpr.ParseDays(arg, now); err == nil {
+				days = parsed
+				daysLabel = formatDays(parsed, now)
+			} else if envFilter == "" && !looksLikeDate(arg) {
+				envFilter = strings.ToLower(arg)
+			} else {
+				respond(w, fmt.Sprintf("❌ %v", err))
+				return
+			}
+		}
 	}
 
-	if h.calClient == nil {
+	if h.store == nil {
 		respond(w, "❌ Calendar not configured. Please set up Google Calendar credentials (see SERVICE_ACCOUNT_SETUP.md)")
 		return
 	}
 
-	now := time.Now()
-	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-	endOfDay := startOfDay.Add(24 * time.Hour)
-
-	events, err := h.calClient.ListEvents(r.Context(), startOfDay, endOfDay)
+	events, err := h.store.ListEvents(r.Context(), days.Start, days.End)
 	if err != nil {
 		slog.Error("Failed to list calendar events", "error", err)
 		respond(w, "❌ Failed to check calendar")
 		return
 	}
 
-	if len(events) == 0 {
-		respond(w, "📅 No bookings for today")
-		return
-	}
-
 	var filteredEvents []domain.Event
 	for _, event := range events {
-		if envFilter == "" || strings.EqualFold(event.Env, envFilter) {
-			filteredEvents = append(filteredEvents, event)
+		if envFilter != "" && !strings.EqualFold(event.Env, envFilter) {
+			continue
+		}
+		// A whole-environment lock holds every service
+		if serviceFilter != "" && !strings.EqualFold(event.Service, serviceFilter) && event.Service != domain.AllServices {
+			continue
+		}
+		if userFilter != "" && !strings.EqualFold(event.User, userFilter) && !strings.EqualFold(event.UserID, userFilter) {
+			continue
 		}
+		filteredEvents = append(filteredEvents, event)
 	}
 
 	if len(filteredEvents) == 0 {
 		if envFilter != "" {
-			respond(w, fmt.Sprintf("📅 No bookings for %s today", envFilter))
+			respond(w, fmt.Sprintf("📅 No bookings for %s %s", envFilter, daysLabel))
 		} else {
-			respond(w, "📅 No bookings for today")
+			respond(w, fmt.Sprintf("📅 No bookings for %s", daysLabel))
 		}
 		return
 	}
 
 	var response strings.Builder
-	response.WriteString(fmt.Sprintf("📅 Bookings for today (%d):\n\n", len(filteredEvents)))
+	response.WriteString(fmt.Sprintf("📅 Bookings for %s (%d), times in %s:\n\n", daysLabel, len(filteredEvents), loc))
 	response.WriteString("```\n")
-	response.WriteString(formatEventsTable(filteredEvents))
+	response.WriteString(formatEventsByDay(filteredEvents, loc))
 	response.WriteString("```")
 
 	respond(w, response.String())
 }
 
-// formatEventsTable creates an ASCII table for the events
-func formatEventsTable(events []domain.Event) string {
+// formatDays describes a ParseDays range for headings, e.g. "tomorrow" or
+// "Mon 02 Jan - Fri 06 Jan"
+func formatDays(days timeexpr.Range, now time.Time) string {
+	last := days.End.AddDate(0, 0, -1)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	if last.Equal(days.Start) {
+		switch {
+		case days.Start.Equal(today):
+			return "today"
+		case days.Start.Equal(today.AddDate(0, 0, 1)):
+			return "tomorrow"
+		}
+		return days.Start.Format("Mon 02 Jan")
+	}
+	return fmt.Sprintf("%s - %s", days.Start.Format("Mon 02 Jan"), last.Format("Mon 02 Jan"))
+}
+
+// looksLikeDate reports whether a list argument was meant as a date, so a
+// mistyped date gets an error rather than being taken for an env
+func looksLikeDate(arg string) bool {
+	return strings.Contains(arg, "..") || (arg[0] >= '0' && arg[0] <= '9')
+}
+
+// normalizeUser strips the decorations people add when naming a user:
+// "@alice", or a Slack mention like "<@U123|alice>" which becomes the ID
+func normalizeUser(user string) string {
+	user = strings.TrimPrefix(strings.TrimSuffix(user, ">"), "<")
+	user = strings.TrimPrefix(user, "@")
+	id, _, _ := strings.Cut(user, "|")
+	return id
+}
+
+// moveBookings moves the bookings to start at slot. Starts are put on the
+// 15-minute grid when that still fits between the existing events.
+func (h *Handler) moveBookings(bookings []domain.Booking, slot time.Time, existingEvents []domain.Event) []domain.Booking {
+	moved := make([]domain.Booking, len(bookings))
+	start := grid.Round(slot)
+	for _, b := range bookings {
+		b.StartTime = start
+		if h.rules.CheckConflict(b, existingEvents) != nil {
+			start = slot
+			break
+		}
+	}
+
+	for i, b := range bookings {
+		b.StartTime = start
+		moved[i] = b
+	}
+	return moved
+}
+
+// slotSearchWindow is how far ahead next-slot searches look when the env
+// has no booking horizon
+const slotSearchWindow = 7 * 24 * time.Hour
+
+// searchWindow returns how far ahead to look for a free slot in env
+func (h *Handler) searchWindow(env string) time.Duration {
+	if horizon := h.rules.Horizon(env); horizon > 0 {
+		return horizon
+	}
+	return slotSearchWindow
+}
+
+// formatWindow renders a search window in days, e.g. "7 days"
+func formatWindow(d time.Duration) string {
+	if days := int(d / (24 * time.Hour)); days > 1 {
+		return fmt.Sprintf("%d days", days)
+	}
+	return d.String()
+}
+
+// splitServices parses a comma-separated service list, dropping blanks and
+// duplicates. "all" or "*" lock the whole environment and cover every other service.
+func splitServices(arg string) []string {
+	var services []string
+	seen := make(map[string]bool)
+	for _, svc := range strings.Split(arg, ",") {
+		svc = normalizeService(svc)
+		if svc == domain.AllServices {
+			return []string{domain.AllServices}
+		}
+		if svc == "" || seen[svc] {
+			continue
+		}
+		seen[svc] = true
+		services = append(services, svc)
+	}
+	return services
+}
+
+// normalizeService lower-cases a service name and maps "all" to the whole-environment lock
+func normalizeService(service string) string {
+	service = strings.ToLower(strings.TrimSpace(service))
+	if service == "all" {
+		return domain.AllServices
+	}
+	return service
+}
+
+// displayService names a service for tables, spelling out whole-environment locks
+func displayService(service string) string {
+	if service == domain.AllServices {
+		return "* (all)"
+	}
+	return service
+}
+
+// splitArgs splits a command into words like strings.Fields, except that a
+// "quoted phrase" stays one word. Slack's curly quotes work too.
+func splitArgs(text string) []string {
+	var args []string
+	var word strings.Builder
+	inWord, quoted := false, false
+
+	for _, r := range text {
+		switch {
+		case r == '"' || r == '“' || r == '”':
+			quoted = !quoted
+			inWord = true
+		case unicode.IsSpace(r) && !quoted:
+			if inWord {
+				args = append(args, word.String())
+				word.Reset()
+			}
+			inWord = false
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if inWord {
+		args = append(args, word.String())
+	}
+	return args
+}
+
+// splitFlags separates "--name [value]" options from positional args.
+// Flags mapped to true in known take the following argument as their value;
+// the others are switches and get the value "true".
+func splitFlags(args []string, known map[string]bool) ([]string, map[string]string, error) {
+	var positional []string
+	flags := make(map[string]string)
+
+	for i := 0; i < len(args); i++ {
+		name, isFlag := strings.CutPrefix(args[i], "--")
+		if !isFlag {
+			positional = append(positional, args[i])
+			continue
+		}
+
+		name = strings.ToLower(name)
+		takesValue, ok := known[name]
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown option: --%s", name)
+		}
+
+		if !takesValue {
+			flags[name] = "true"
+			continue
+		}
+		if i+1 >= len(args) {
+			return nil, nil, fmt.Errorf("option --%s needs a value", name)
+		}
+		flags[name] = args[i+1]
+		i++
+	}
+
+	return positional, flags, nil
+}
+
+// parseWhen parses the start time and optional duration that follow a
+// booking, e.g. "tomorrow 14:00 30m" or "14:00-15:30". A trailing duration is
+// only split off when the whole expression doesn't parse on its own, so
+// "in 45m" is a start time. The returned duration is 0 when none was given.
+func parseWhen(args []string, now time.Time) (time.Time, time.Duration, error) {
+	r, err := timeexpr.Parse(strings.Join(args, " "), now)
+	if err != nil && len(args) > 1 {
+		d, dErr := time.ParseDuration(args[len(args)-1])
+		if dErr != nil {
+			return time.Time{}, 0, err
+		}
+		r, err = timeexpr.Parse(strings.Join(args[:len(args)-1], " "), now)
+		if err != nil {
+			return time.Time{}, 0, err
+		}
+		if !r.End.IsZero() {
+			return time.Time{}, 0, fmt.Errorf("give either an end time or a duration, not both")
+		}
+		return r.Start, d, nil
+	}
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+
+	if r.End.IsZero() {
+		return r.Start, 0, nil
+	}
+	// The start is rounded to the grid later, so measure from there
+	return r.Start, r.End.Sub(grid.Round(r.Start)), nil
+}
+
+// formatEventsByDay groups events by the day they start on in loc, with a
+// table under each day's heading
+func formatEventsByDay(events []domain.Event, loc *time.Location) string {
+	sorted := make([]domain.Event, len(events))
+	copy(sorted, events)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].StartTime.Before(sorted[j].StartTime)
+	})
+
+	var sb strings.Builder
+	for len(sorted) > 0 {
+		day := sorted[0].StartTime.In(loc).Format("Mon 02 Jan")
+		n := 1
+		for n < len(sorted) && sorted[n].StartTime.In(loc).Format("Mon 02 Jan") == day {
+			n++
+		}
+
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(day + "\n")
+		sb.WriteString(formatEventsTable(sorted[:n], loc))
+		sorted = sorted[n:]
+	}
+
+	return sb.String()
+}
+
+// formatEventsTable creates an ASCII table for the events, with times in loc
+func formatEventsTable(events []domain.Event, loc *time.Location) string {
 	if len(events) == 0 {
 		return ""
 	}
@@ -444,8 +1127,8 @@ func formatEventsTable(events []domain.Event) string {
 		if len(e.Env) > envWidth {
 			envWidth = len(e.Env)
 		}
-		if len(e.Service) > svcWidth {
-			svcWidth = len(e.Service)
+		if len(displayService(e.Service)) > svcWidth {
+			svcWidth = len(displayService(e.Service))
 		}
 		// Truncate title if too long? User asked to show all.
 		// So we just take the full length.
@@ -471,34 +1154,67 @@ func formatEventsTable(events []domain.Event) string {
 
 	// Rows
 	for _, e := range events {
-		timeStr := fmt.Sprintf("%s - %s", e.StartTime.Format("15:04"), e.EndTime.Format("15:04"))
+		timeStr := fmt.Sprintf("%s - %s", e.StartTime.In(loc).Format("15:04"), e.EndTime.In(loc).Format("15:04"))
 		sb.WriteString(fmt.Sprintf("%-*s | %-*s | %-*s | %-*s\n",
 			timeWidth, timeStr,
 			envWidth, e.Env,
-			svcWidth, e.Service,
+			svcWidth, displayService(e.Service),
 			titleWidth, e.Title))
 	}
 
 	return sb.String()
 }
 
-func respond(w http.ResponseWriter, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	fmt.Fprintf(w, `{"text": "%s"}`, message)
-}
+// formatBookingsTable creates an ASCII table of bookings spanning several
+// days, including the booking IDs that other commands accept. Times are in loc.
+func formatBookingsTable(events []domain.Event, loc *time.Location) string {
+	if len(events) == 0 {
+		return ""
+	}
+
+	// Columns: ID | When | Env | Service | Jira
+	idWidth := 2    // "ID"
+	whenWidth := 24 // "Mon 02 Jan 15:04 - 15:04"
+	envWidth := 3   // "Env"
+	svcWidth := 7   // "Service"
+	jiraWidth := 4  // "Jira"
+
+	for _, e := range events {
+		idWidth = max(idWidth, len(e.ID))
+		envWidth = max(envWidth, len(e.Env))
+		svcWidth = max(svcWidth, len(displayService(e.Service)))
+		jiraWidth = max(jiraWidth, len(e.JiraTicket))
+	}
 
-// roundToQuarterHour rounds a time to the nearest 15-minute interval
-// (00, 15, 30, or 45 minutes)
-func roundToQuarterHour(t time.Time) time.Time {
-	minutes := t.Minute()
+	var sb strings.Builder
 
-	// Round to nearest 15-minute mark
-	roundedMinutes := ((minutes + 7) / 15) * 15
+	sb.WriteString(fmt.Sprintf("%-*s | %-*s | %-*s | %-*s | %-*s\n",
+		idWidth, "ID",
+		whenWidth, "When",
+		envWidth, "Env",
+		svcWidth, "Service",
+		jiraWidth, "Jira"))
 
-	// Handle overflow to next hour
-	if roundedMinutes >= 60 {
-		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+	sb.WriteString(strings.Repeat("-", idWidth) + "-+-" +
+		strings.Repeat("-", whenWidth) + "-+-" +
+		strings.Repeat("-", envWidth) + "-+-" +
+		strings.Repeat("-", svcWidth) + "-+-" +
+		strings.Repeat("-", jiraWidth) + "\n")
+
+	for _, e := range events {
+		whenStr := fmt.Sprintf("%s - %s", e.StartTime.In(loc).Format("Mon 02 Jan 15:04"), e.EndTime.In(loc).Format("15:04"))
+		sb.WriteString(fmt.Sprintf("%-*s | %-*s | %-*s | %-*s | %-*s\n",
+			idWidth, e.ID,
+			whenWidth, whenStr,
+			envWidth, e.Env,
+			svcWidth, displayService(e.Service),
+			jiraWidth, e.JiraTicket))
 	}
 
-	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), roundedMinutes, 0, 0, t.Location())
+	return sb.String()
+}
+
+func respond(w http.ResponseWriter, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprintf(w, `{"text": "%s"}`, message)
 }
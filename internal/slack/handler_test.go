@@ -0,0 +1,514 @@
+package slack
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yossigruner/SlotBot/internal/calendar"
+	"github.com/yossigruner/SlotBot/internal/config"
+	"github.com/yossigruner/SlotBot/internal/domain"
+	"github.com/yossigruner/SlotBot/internal/waitlist"
+)
+
+// newTestHandler creates a Handler with default configuration and an
+// in-memory waitlist around store.
+func newTestHandler(store calendar.BookingStore) *Handler {
+	return newTestHandlerWithRules(store, calendar.NewRules(nil, nil, nil))
+}
+
+// newTestHandlerWithRules is newTestHandler booking under rules.
+func newTestHandlerWithRules(store calendar.BookingStore, rules *calendar.Rules) *Handler {
+	queue, _ := waitlist.Open("")
+	return NewHandler(store, rules, waitlist.NewDispatcher(queue, store, rules, nil, nil, nil), nil, nil, nil, &config.Config{
+		MineHorizon: 14 * 24 * time.Hour,
+	})
+}
+
+// slotCommand sends a /slot command from the default test user and returns the response body.
+func slotCommand(t *testing.T, h *Handler, text string) string {
+	t.Helper()
+	return slotCommandAs(t, h, "U123", "alice", text)
+}
+
+// slotCommandAs sends a /slot command through HandleUnified and returns the response body.
+func slotCommandAs(t *testing.T, h *Handler, userID, userName, text string) string {
+	t.Helper()
+
+	form := url.Values{}
+	form.Set("text", text)
+	form.Set("user_id", userID)
+	form.Set("user_name", userName)
+
+	req := httptest.NewRequest(http.MethodPost, "/slack/slot", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+
+	h.HandleUnified(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("HandleUnified(%q) status = %d, want %d", text, rec.Code, http.StatusOK)
+	}
+	return rec.Body.String()
+}
+
+func TestHandleBook(t *testing.T) {
+	h := newTestHandler(calendar.NewMemoryStore())
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "Book free slot",
+			text: "book staging api PROJ-1 1h",
+			want: "Booked!",
+		},
+		{
+			name: "Book same slot again",
+			text: "book staging api PROJ-2 1h",
+			want: "Conflict detected!",
+		},
+		{
+			name: "Book other service",
+			text: "book staging web PROJ-3 1h",
+			want: "Booked!",
+		},
+		{
+			name: "Taken slot with --asap",
+			text: "book staging api PROJ-13 1h 1h --asap",
+			want: "you got the next available slot",
+		},
+		{
+			name: "Several services with one taken",
+			text: "book staging web,worker PROJ-8 1h",
+			want: "Conflict detected!",
+		},
+		{
+			name: "Several free services",
+			text: "book staging worker,db PROJ-9 1h",
+			want: "Booked!",
+		},
+		{
+			name: "Lock an environment in use",
+			text: "book staging all PROJ-10 1h",
+			want: "Conflict detected!",
+		},
+		{
+			name: "Lock a free environment",
+			text: "book qa all PROJ-11 1h",
+			want: "* (all)",
+		},
+		{
+			name: "Book a service in a locked environment",
+			text: "book qa api PROJ-12 1h",
+			want: "Conflict detected!",
+		},
+		{
+			name: "Invalid env",
+			text: "book prod api PROJ-4",
+			want: "Validation error",
+		},
+		{
+			name: "Recurring over an existing booking",
+			text: "book staging api PROJ-5 1h 1h --every daily --until " + time.Now().AddDate(0, 0, 3).Format(time.DateOnly),
+			want: "occurrences conflict",
+		},
+		{
+			name: "Recurring without end date",
+			text: "book qa api PROJ-6 --every daily",
+			want: "need an end date",
+		},
+		{
+			name: "Start time in the past",
+			text: "book demo api PROJ-14 2020-01-01T10:00:00Z",
+			want: "in the past",
+		},
+		{
+			name: "Unrecognised start time",
+			text: "book demo api PROJ-15 someday 14:00",
+			want: "Invalid start time",
+		},
+		{
+			name: "End time and duration",
+			text: "book demo api PROJ-16 until 23:59 30m",
+			want: "either an end time or a duration",
+		},
+		{
+			name: "Relative start with duration",
+			text: "book demo web PROJ-17 in 3h 30m",
+			want: "Booked!",
+		},
+		{
+			name: "Unknown option",
+			text: "book qa api PROJ-7 --often",
+			want: "unknown option",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := slotCommand(t, h, tt.text); !strings.Contains(got, tt.want) {
+				t.Errorf("HandleUnified(%q) = %q, want it to contain %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleBookASAPLater(t *testing.T) {
+	store := calendar.NewMemoryStore()
+	h := newTestHandler(store)
+
+	slotCommandAs(t, h, "U999", "bob", "book staging api PROJ-1 tomorrow 10:00 1h")
+
+	// The next slot is after the requested start, not the first one from now
+	got := slotCommand(t, h, "book staging api PROJ-2 tomorrow 10:00 1h --asap")
+	if !strings.Contains(got, "10:00 was taken, so you got the next available slot at") || !strings.Contains(got, "11:00") {
+		t.Fatalf("book --asap = %q, want it moved to 11:00", got)
+	}
+
+	tomorrow := time.Now().AddDate(0, 0, 1)
+	want := time.Date(tomorrow.Year(), tomorrow.Month(), tomorrow.Day(), 11, 0, 0, 0, time.Local)
+	events, err := store.ListEvents(context.Background(), want.Add(-time.Minute), want.Add(time.Minute))
+	if err != nil || !slices.ContainsFunc(events, func(e domain.Event) bool { return e.JiraTicket == "PROJ-2" && e.StartTime.Equal(want) }) {
+		t.Errorf("stored events = %v, %v, want PROJ-2 at %v", events, err, want)
+	}
+}
+
+func TestHandleCancel(t *testing.T) {
+	h := newTestHandler(calendar.NewMemoryStore())
+
+	slotCommand(t, h, "book staging api PROJ-1 1h")
+
+	if got := slotCommandAs(t, h, "U999", "bob", "cancel staging api"); !strings.Contains(got, "no current or upcoming booking") {
+		t.Errorf("cancel by another user = %q, want no booking found", got)
+	}
+	if got := slotCommand(t, h, "cancel staging api"); !strings.Contains(got, "Cancelled!") {
+		t.Errorf("cancel by owner = %q, want Cancelled!", got)
+	}
+	if got := slotCommand(t, h, "cancel"); !strings.Contains(got, "no current or upcoming bookings") {
+		t.Errorf("cancel with nothing booked = %q, want no bookings", got)
+	}
+}
+
+func TestHandleCancelRecurring(t *testing.T) {
+	store := calendar.NewMemoryStore()
+	h := newTestHandler(store)
+
+	until := time.Now().AddDate(0, 0, 4).Format(time.DateOnly)
+	got := slotCommand(t, h, "book staging api PROJ-1 tomorrow 10:00 --every daily --until "+until)
+	match := regexp.MustCompile("Booking ID: `([^`]+)`").FindStringSubmatch(got)
+	if match == nil {
+		t.Fatalf("book = %q, want a booking ID", got)
+	}
+
+	if got := slotCommandAs(t, h, "U999", "bob", "cancel "+match[1]); !strings.Contains(got, "belongs to someone else") {
+		t.Errorf("cancel by another user = %q, want refused", got)
+	}
+	if got := slotCommand(t, h, "cancel "+match[1]); !strings.Contains(got, "Cancelled the whole recurring booking") {
+		t.Fatalf("cancel %s = %q, want the series cancelled", match[1], got)
+	}
+
+	events, err := store.ListEvents(context.Background(), time.Now(), time.Now().AddDate(0, 0, 7))
+	if err != nil || len(events) != 0 {
+		t.Errorf("events after cancel = %v, %v, want none", events, err)
+	}
+}
+
+func TestHandleExtend(t *testing.T) {
+	store := calendar.NewMemoryStore()
+	h := newTestHandler(store)
+
+	_, err := store.CreateEvent(context.Background(), domain.Booking{
+		Env:        "staging",
+		Service:    "api",
+		JiraTicket: "PROJ-1",
+		StartTime:  time.Now().Add(-30 * time.Minute),
+		Duration:   time.Hour,
+		User:       "alice",
+		UserID:     "U123",
+	})
+	if err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	slotCommand(t, h, "book qa web PROJ-2 tomorrow 10:00")
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "Extend within limit",
+			text: "extend staging api 30m",
+			want: "Extended by 30m0s!",
+		},
+		{
+			name: "Extend past maximum duration",
+			text: "extend 1h",
+			want: "maximum booking duration",
+		},
+		{
+			name: "Invalid duration",
+			text: "extend soon",
+			want: "Invalid duration",
+		},
+		{
+			name: "Upcoming booking",
+			text: "extend qa web 30m",
+			want: "You have no active booking for qa / web",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := slotCommand(t, h, tt.text); !strings.Contains(got, tt.want) {
+				t.Errorf("HandleUnified(%q) = %q, want it to contain %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleDone(t *testing.T) {
+	store := calendar.NewMemoryStore()
+	h := newTestHandler(store)
+
+	now := time.Now()
+	_, err := store.CreateEvent(context.Background(), domain.Booking{
+		Env:        "staging",
+		Service:    "api",
+		JiraTicket: "PROJ-1",
+		StartTime:  now.Add(-time.Hour),
+		Duration:   2 * time.Hour,
+		User:       "alice",
+		UserID:     "U123",
+	})
+	if err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+
+	if got := slotCommand(t, h, "done staging api"); !strings.Contains(got, "Released!") {
+		t.Fatalf("done = %q, want Released!", got)
+	}
+
+	if got := slotCommand(t, h, "current staging"); !strings.Contains(got, "No active bookings") {
+		t.Errorf("current after done = %q, want no active bookings", got)
+	}
+	if got := slotCommand(t, h, "done"); !strings.Contains(got, "no active bookings") {
+		t.Errorf("done twice = %q, want no active bookings", got)
+	}
+}
+
+func TestHandleMine(t *testing.T) {
+	h := newTestHandler(calendar.NewMemoryStore())
+
+	slotCommand(t, h, "book staging api PROJ-1 1h")
+	slotCommandAs(t, h, "U999", "bob", "book qa web PROJ-2 1h")
+
+	got := slotCommand(t, h, "mine")
+	if !strings.Contains(got, "PROJ-1") || strings.Contains(got, "PROJ-2") {
+		t.Errorf("mine = %q, want only the caller's booking", got)
+	}
+
+	// Same user name, different Slack user: stored user IDs decide ownership
+	if got := slotCommandAs(t, h, "U777", "alice", "mine"); !strings.Contains(got, "no bookings") {
+		t.Errorf("mine for another user named alice = %q, want no bookings", got)
+	}
+}
+
+func TestHandleNext(t *testing.T) {
+	h := newTestHandler(calendar.NewMemoryStore())
+
+	slotCommand(t, h, "book staging api PROJ-1 tomorrow 10:00 2h")
+
+	got := slotCommand(t, h, "next staging api 1h --after tomorrow --count 2")
+	tomorrow := time.Now().AddDate(0, 0, 1).Format("Mon 02 Jan")
+	if !strings.Contains(got, tomorrow+" 00:00 - 10:00 (10h free)") || !strings.Contains(got, tomorrow+" 12:00 onwards") {
+		t.Errorf("next = %q, want the gaps around the booking", got)
+	}
+
+	if got := slotCommand(t, h, "next staging api --count 0"); !strings.Contains(got, "Invalid --count") {
+		t.Errorf("next with --count 0 = %q, want an error", got)
+	}
+}
+
+func TestHandleList(t *testing.T) {
+	h := newTestHandler(calendar.NewMemoryStore())
+
+	slotCommand(t, h, "book staging api PROJ-1 tomorrow 10:00")
+	slotCommandAs(t, h, "U999", "bob", "book qa web PROJ-2 tomorrow 11:00")
+
+	tomorrow := time.Now().AddDate(0, 0, 1).Format("Mon 02 Jan")
+
+	tests := []struct {
+		name    string
+		text    string
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "Today",
+			text:    "list staging",
+			want:    []string{"No bookings for staging today"},
+			notWant: []string{"PROJ-1"},
+		},
+		{
+			name: "Tomorrow grouped by day",
+			text: "list tomorrow",
+			want: []string{"Bookings for tomorrow (2)", tomorrow, "PROJ-1", "PROJ-2"},
+		},
+		{
+			name:    "Env and range",
+			text:    "list qa today..tomorrow",
+			want:    []string{"PROJ-2"},
+			notWant: []string{"PROJ-1"},
+		},
+		{
+			name:    "User filter",
+			text:    "list week user=@bob",
+			want:    []string{"PROJ-2"},
+			notWant: []string{"PROJ-1"},
+		},
+		{
+			name:    "Service filter",
+			text:    "list week service=api",
+			want:    []string{"PROJ-1"},
+			notWant: []string{"PROJ-2"},
+		},
+		{
+			name: "Bad date",
+			text: "list 2026-13-40",
+			want: []string{"unrecognised day"},
+		},
+		{
+			name: "Unknown filter",
+			text: "list jira=PROJ-1",
+			want: []string{"Unknown filter"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := slotCommand(t, h, tt.text)
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("HandleUnified(%q) = %q, want it to contain %q", tt.text, got, want)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(got, notWant) {
+					t.Errorf("HandleUnified(%q) = %q, want it not to contain %q", tt.text, got, notWant)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleGrid(t *testing.T) {
+	h := newTestHandler(calendar.NewMemoryStore())
+
+	slotCommand(t, h, "book staging api PROJ-1 tomorrow 10:00")
+
+	if got := slotCommand(t, h, "grid staging tomorrow"); !strings.Contains(got, "api  ........####....") {
+		t.Errorf("grid = %q, want api busy 10:00-11:00", got)
+	}
+	if got := slotCommand(t, h, "grid qa tomorrow"); !strings.Contains(got, "free all day") {
+		t.Errorf("grid for a free env = %q, want free all day", got)
+	}
+	if got := slotCommand(t, h, "grid staging week"); !strings.Contains(got, "single day") {
+		t.Errorf("grid for a week = %q, want a single-day error", got)
+	}
+}
+
+func TestHandleMoveAndEdit(t *testing.T) {
+	store := calendar.NewMemoryStore()
+	h := newTestHandler(store)
+
+	now := time.Now()
+	mine, err := store.CreateEvent(context.Background(), domain.Booking{
+		Env:        "staging",
+		Service:    "api",
+		JiraTicket: "PROJ-1",
+		StartTime:  now.Add(2 * time.Hour),
+		Duration:   time.Hour,
+		User:       "alice",
+		UserID:     "U123",
+	})
+	if err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	slotCommandAs(t, h, "U999", "bob", "book staging api PROJ-2 5h 1h")
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "Move onto the other booking",
+			text: "move " + mine.ID + " 5h",
+			want: "Conflict detected!",
+		},
+		{
+			name: "Move overlapping itself",
+			text: "move " + mine.ID + " 150m 30m",
+			want: "Moved!",
+		},
+		{
+			name: "Move beyond maximum duration",
+			text: "move " + mine.ID + " 2h 3h",
+			want: "maximum booking duration",
+		},
+		{
+			name: "Edit Jira ticket",
+			text: "edit " + mine.ID + " jira=OG-42",
+			want: "OG-42",
+		},
+		{
+			name: "Edit unknown field",
+			text: "edit " + mine.ID + " env=qa",
+			want: "Unknown field",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := slotCommand(t, h, tt.text); !strings.Contains(got, tt.want) {
+				t.Errorf("HandleUnified(%q) = %q, want it to contain %q", tt.text, got, tt.want)
+			}
+		})
+	}
+
+	if got := slotCommandAs(t, h, "U999", "bob", "edit "+mine.ID+" jira=OG-1"); !strings.Contains(got, "belongs to someone else") {
+		t.Errorf("edit by another user = %q, want ownership error", got)
+	}
+}
+
+func TestHandleQueue(t *testing.T) {
+	h := newTestHandler(calendar.NewMemoryStore())
+
+	slotCommandAs(t, h, "U999", "bob", "book staging api PROJ-1")
+
+	if got := slotCommand(t, h, "queue staging api PROJ-2 30m"); !strings.Contains(got, "#1 in line") {
+		t.Fatalf("queue for a busy service = %q, want first in line", got)
+	}
+	if got := slotCommand(t, h, "queue staging api PROJ-2 30m"); !strings.Contains(got, "already waiting") {
+		t.Errorf("queue twice = %q, want already waiting", got)
+	}
+	if got := slotCommand(t, h, "queue qa web PROJ-3 30m"); !strings.Contains(got, "was free - booked for you") {
+		t.Errorf("queue for a free service = %q, want booked", got)
+	}
+	if got := slotCommand(t, h, "queue"); !strings.Contains(got, "#1 for staging / api") {
+		t.Errorf("queue positions = %q, want staging / api", got)
+	}
+	if got := slotCommand(t, h, "unqueue staging api"); !strings.Contains(got, "left the waitlist") {
+		t.Errorf("unqueue = %q, want left the waitlist", got)
+	}
+}
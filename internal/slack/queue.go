@@ -0,0 +1,169 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/yossigruner/SlotBot/internal/domain"
+	"github.com/yossigruner/SlotBot/internal/waitlist"
+)
+
+func (h *Handler) handleQueueSubcommand(w http.ResponseWriter, r *http.Request, args []string) {
+	loc := h.userLocation(r)
+	if h.waitlist == nil {
+		respond(w, "❌ The waitlist is not enabled")
+		return
+	}
+
+	if len(args) == 0 {
+		h.respondUserQueue(w, r)
+		return
+	}
+
+	if len(args) < 3 || len(args) > 4 {
+		respond(w, "Usage: `/slot queue <env> <service> <jira> [duration]`")
+		return
+	}
+
+	if strings.Contains(args[1], ",") {
+		respond(w, "❌ The waitlist takes one service at a time")
+		return
+	}
+	env, services, ok := h.resolveNames(w, args[0], []string{normalizeService(args[1])})
+	if !ok {
+		return
+	}
+	service := services[0]
+	jira := args[2]
+	if !jiraRegex.MatchString(jira) {
+		respond(w, "❌ Invalid Jira ticket format. Must be like PROJ-123 or OG-1234")
+		return
+	}
+
+	duration := time.Hour
+	if len(args) > 3 {
+		d, err := time.ParseDuration(args[3])
+		if err != nil {
+			respond(w, fmt.Sprintf("❌ Invalid duration: %s. Use a value like 30m or 1h", args[3]))
+			return
+		}
+		duration = d
+	}
+
+	// Joining is fine while the env is closed; the booking starts once it opens
+	booking := domain.Booking{
+		Env:        env,
+		Service:    service,
+		JiraTicket: jira,
+		StartTime:  h.rules.NextOpen(env, time.Now()),
+		Duration:   duration,
+		User:       r.FormValue("user_name"),
+		UserID:     r.FormValue("user_id"),
+	}
+	if err := h.rules.ValidateBooking(booking); err != nil {
+		respond(w, fmt.Sprintf("❌ Validation error: %v", err))
+		return
+	}
+
+	entry, position, err := h.waitlist.Queue().Add(waitlist.Entry{
+		Env:        env,
+		Service:    service,
+		JiraTicket: strings.ToUpper(jira),
+		Duration:   duration,
+		User:       r.FormValue("user_name"),
+		UserID:     r.FormValue("user_id"),
+	})
+	if err != nil {
+		slog.Error("Failed to join waitlist", "error", err)
+		respond(w, fmt.Sprintf("❌ Could not join the waitlist: %v", err))
+		return
+	}
+
+	slog.Info("Joined waitlist", "env", env, "service", service, "user", entry.User, "position", position)
+
+	// If it happens to be free already, the entry is booked straight away
+	for _, event := range h.waitlist.Process(r.Context(), env) {
+		if event.UserID == entry.UserID && event.Env == entry.Env && event.Service == entry.Service {
+			respond(w, fmt.Sprintf("✅ %s / %s was free - booked for you!\n```\n%s```\nBooking ID: `%s`",
+				env, displayService(service), formatEventsTable([]domain.Event{event}, loc), event.ID))
+			return
+		}
+	}
+
+	respond(w, fmt.Sprintf("⏳ You're #%d in line for %s / %s (%s, %s).\nWhen it frees up I'll book it for you and send you a DM. Leave the line with `/slot unqueue %s %s`",
+		position, env, displayService(service), strings.ToUpper(jira), duration, env, service))
+}
+
+func (h *Handler) handleUnqueueSubcommand(w http.ResponseWriter, r *http.Request, args []string) {
+	if h.waitlist == nil {
+		respond(w, "❌ The waitlist is not enabled")
+		return
+	}
+
+	if len(args) != 2 {
+		respond(w, "Usage: `/slot unqueue <env> <service>`")
+		return
+	}
+
+	env, services, ok := h.resolveNames(w, args[0], []string{normalizeService(args[1])})
+	if !ok {
+		return
+	}
+	service := services[0]
+
+	userID := r.FormValue("user_id")
+	for _, entry := range h.waitlist.Queue().Entries() {
+		if entry.UserID != userID || !strings.EqualFold(entry.Env, env) || entry.Service != service {
+			continue
+		}
+
+		if _, err := h.waitlist.Queue().Remove(entry.ID); err != nil {
+			slog.Error("Failed to leave waitlist", "error", err)
+			respond(w, "❌ Failed to leave the waitlist")
+			return
+		}
+		respond(w, fmt.Sprintf("👋 You left the waitlist for %s / %s", entry.Env, displayService(entry.Service)))
+		return
+	}
+
+	respond(w, fmt.Sprintf("❌ You are not waiting for %s / %s", env, displayService(service)))
+}
+
+// respondUserQueue lists the waitlists the caller is in
+func (h *Handler) respondUserQueue(w http.ResponseWriter, r *http.Request) {
+	userID := r.FormValue("user_id")
+
+	var lines []string
+	for _, entry := range h.waitlist.Queue().Entries() {
+		if entry.UserID != userID {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("• #%d for %s / %s (%s, %s)",
+			h.waitlist.Queue().Position(entry.ID), entry.Env, displayService(entry.Service), entry.JiraTicket, entry.Duration))
+	}
+
+	if len(lines) == 0 {
+		respond(w, "⏳ You are not on any waitlist. Join one with `/slot queue <env> <service> <jira> [duration]`")
+		return
+	}
+
+	respond(w, "⏳ Your waitlist positions:\n"+strings.Join(lines, "\n"))
+}
+
+// releaseToWaitlist hands freed time in env to the waitlist in the
+// background, so the Slack reply isn't delayed.
+func (h *Handler) releaseToWaitlist(env string) {
+	if h.waitlist == nil {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		h.waitlist.Process(ctx, env)
+	}()
+}
@@ -0,0 +1,106 @@
+package slack
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/yossigruner/SlotBot/internal/calendar"
+	"github.com/yossigruner/SlotBot/internal/domain"
+)
+
+// checkQuota answers and returns false when the bookings would take the user
+// over a quota
+func (h *Handler) checkQuota(w http.ResponseWriter, r *http.Request, bookings ...domain.Booking) bool {
+	err := h.rules.CheckQuota(r.Context(), h.store, bookings, h.teams)
+	if err == nil {
+		return true
+	}
+
+	var quotaErr *calendar.PolicyError
+	if errors.As(err, &quotaErr) {
+		respond(w, fmt.Sprintf("❌ Over quota: %v\nSee your usage with `/slot quota`", err))
+		return false
+	}
+	slog.Error("Failed to check quota", "error", err)
+	respond(w, "❌ Failed to check calendar")
+	return false
+}
+
+func (h *Handler) handleQuotaSubcommand(w http.ResponseWriter, r *http.Request, args []string) {
+	if len(args) > 1 {
+		respond(w, "Usage: `/slot quota [@user]`")
+		return
+	}
+
+	if h.store == nil {
+		respond(w, "❌ Calendar not configured. Please set up Google Calendar credentials (see SERVICE_ACCOUNT_SETUP.md)")
+		return
+	}
+
+	userID, userName := r.FormValue("user_id"), r.FormValue("user_name")
+	who := "You have"
+	if len(args) == 1 {
+		// A mention carries the user's ID; a plain name matches by name only
+		userID, userName = "", normalizeUser(args[0])
+		if strings.HasPrefix(args[0], "<@") {
+			userID, userName = userName, ""
+		}
+		who = normalizeUser(args[0]) + " has"
+	}
+
+	usages, err := h.rules.QuotaUsages(r.Context(), h.store, userID, userName, h.teams, time.Now())
+	if err != nil {
+		slog.Error("Failed to list quota usage", "error", err)
+		respond(w, "❌ Failed to check calendar")
+		return
+	}
+	if len(usages) == 0 {
+		respond(w, "📊 No quotas apply - book as much as you need")
+		return
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "📊 %s booked:", who)
+	for _, u := range usages {
+		if u.Team == "" {
+			fmt.Fprintf(&sb, "\n*%s*: %s", u.Env, formatUsage(u))
+		} else {
+			fmt.Fprintf(&sb, "\n*%s* (team %s): %s", u.Env, u.Team, formatUsage(u))
+		}
+	}
+	respond(w, sb.String())
+}
+
+// formatUsage lists each limit of the quota with how much is used and left,
+// e.g. "today 1h of 4h (3h left) · 1 of 2 at once"
+func formatUsage(u calendar.QuotaUsage) string {
+	var parts []string
+	if u.Quota.PerDay > 0 {
+		parts = append(parts, fmt.Sprintf("today %s of %s (%s left)", formatHours(u.Day), formatHours(u.Quota.PerDay), formatHours(max(u.Quota.PerDay-u.Day, 0))))
+	}
+	if u.Quota.PerWeek > 0 {
+		parts = append(parts, fmt.Sprintf("this week %s of %s (%s left)", formatHours(u.Week), formatHours(u.Quota.PerWeek), formatHours(max(u.Quota.PerWeek-u.Week, 0))))
+	}
+	if u.Quota.Concurrent > 0 {
+		parts = append(parts, fmt.Sprintf("%d of %d at once", u.Concurrent, u.Quota.Concurrent))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// formatHours renders booked time rounded to the minute, e.g. "2h30m". Unlike
+// formatFree it never switches to days, so weekly quotas read in hours.
+func formatHours(d time.Duration) string {
+	d = d.Round(time.Minute)
+	hours, minutes := int(d.Hours()), int(d.Minutes())%60
+	switch {
+	case hours == 0:
+		return fmt.Sprintf("%dm", minutes)
+	case minutes == 0:
+		return fmt.Sprintf("%dh", hours)
+	}
+	return fmt.Sprintf("%dh%02dm", hours, minutes)
+}
@@ -0,0 +1,75 @@
+package slack
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yossigruner/SlotBot/internal/calendar"
+	"github.com/yossigruner/SlotBot/internal/domain"
+)
+
+func TestHandleQuota(t *testing.T) {
+	policy, err := calendar.ParsePolicy([]byte("envs:\n  staging:\n    quota:\n      per_day: 3h\n      concurrent: 2\n  qa: {}"))
+	if err != nil {
+		t.Fatalf("ParsePolicy() error = %v", err)
+	}
+	h := newTestHandlerWithRules(calendar.NewMemoryStore(), calendar.NewRules(policy, nil, nil))
+
+	if got := slotCommand(t, h, "quota"); !strings.Contains(got, "*staging*: today 0m of 3h (3h left) · 0 of 2 at once") {
+		t.Errorf("quota before booking = %q, want staging unused", got)
+	}
+
+	if got := slotCommand(t, h, "book staging api PROJ-1 tomorrow 10:00 2h"); !strings.Contains(got, "Booked!") {
+		t.Fatalf("book within quota = %q, want booked", got)
+	}
+
+	tests := []struct {
+		name string
+		user string
+		text string
+		want string
+	}{
+		{"Over daily quota", "U123", "book staging web PROJ-2 tomorrow 13:00 2h", "Over quota: that goes over your staging quota of 3h per day: 1h left on"},
+		{"Rule is named", "U123", "book staging web PROJ-2 tomorrow 13:00 2h", "(policy rule envs.staging.quota.per_day)"},
+		{"Someone else's quota", "U999", "book staging web PROJ-3 tomorrow 13:00 2h", "Booked!"},
+		{"Env without quota", "U123", "book qa web PROJ-4 tomorrow 13:00 2h", "Booked!"},
+		{"Usage of another user", "U123", "quota <@U999|bob>", "U999 has booked"},
+		{"Usage", "U123", "quota too many", "Usage: `/slot quota [@user]`"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := slotCommandAs(t, h, tt.user, "user-"+tt.user, tt.text); !strings.Contains(got, tt.want) {
+				t.Errorf("HandleUnified(%q) = %q, want it to contain %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleExtendOverQuota(t *testing.T) {
+	policy, err := calendar.ParsePolicy([]byte("envs:\n  staging:\n    quota:\n      concurrent: 2"))
+	if err != nil {
+		t.Fatalf("ParsePolicy() error = %v", err)
+	}
+	store := calendar.NewMemoryStore()
+	h := newTestHandlerWithRules(store, calendar.NewRules(policy, nil, nil))
+
+	// An active booking, and two later ones the extension would run into
+	now := time.Now()
+	for _, b := range []domain.Booking{
+		{Service: "api", StartTime: now.Add(-30 * time.Minute), Duration: time.Hour},
+		{Service: "web", StartTime: now.Add(time.Hour), Duration: 30 * time.Minute},
+		{Service: "db", StartTime: now.Add(time.Hour), Duration: 30 * time.Minute},
+	} {
+		b.Env, b.JiraTicket, b.User, b.UserID = "staging", "PROJ-1", "alice", "U123"
+		if _, err := store.CreateEvent(context.Background(), b); err != nil {
+			t.Fatalf("CreateEvent() error = %v", err)
+		}
+	}
+
+	if got := slotCommand(t, h, "extend staging api 2h"); !strings.Contains(got, "Over quota") {
+		t.Errorf("extend = %q, want over quota", got)
+	}
+}
@@ -0,0 +1,55 @@
+package slack
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// How long the user group list is trusted, and how long to wait before
+// retrying after a failed lookup
+const (
+	teamsTTL   = 10 * time.Minute
+	teamsRetry = time.Minute
+)
+
+// Teams lists the workspace's Slack user groups, which team quotas are kept
+// per, caching them so bookings don't wait on usergroups.list every time.
+type Teams struct {
+	client *APIClient
+
+	mu      sync.Mutex
+	members map[string][]string
+	expires time.Time
+}
+
+func NewTeams(client *APIClient) *Teams {
+	return &Teams{client: client}
+}
+
+// TeamMembers returns the member user IDs per user group handle. When Slack
+// can't be reached it keeps using the last list it got. A nil Teams has no
+// teams.
+func (t *Teams) TeamMembers(ctx context.Context) map[string][]string {
+	if t == nil || t.client == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if time.Now().Before(t.expires) {
+		return t.members
+	}
+
+	members, err := t.client.UserGroups(ctx)
+	if err != nil {
+		slog.Warn("Failed to list Slack user groups, team quotas use the last known teams", "error", err)
+		t.expires = time.Now().Add(teamsRetry)
+		return t.members
+	}
+
+	t.members = members
+	t.expires = time.Now().Add(teamsTTL)
+	return members
+}
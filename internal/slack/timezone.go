@@ -0,0 +1,85 @@
+package slack
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// How long a looked-up timezone is trusted, and how long to wait before
+// retrying after a failed lookup
+const (
+	timezoneTTL   = 12 * time.Hour
+	timezoneRetry = 5 * time.Minute
+)
+
+// Timezones resolves Slack users to their profile timezone, caching the
+// answers so commands don't wait on users.info every time.
+type Timezones struct {
+	client   *APIClient
+	fallback *time.Location
+
+	mu    sync.Mutex
+	cache map[string]cachedLocation
+}
+
+type cachedLocation struct {
+	loc     *time.Location
+	expires time.Time
+}
+
+// NewTimezones creates a resolver that uses fallback for users whose
+// timezone can't be looked up.
+func NewTimezones(client *APIClient, fallback *time.Location) *Timezones {
+	if fallback == nil {
+		fallback = time.Local
+	}
+	return &Timezones{
+		client:   client,
+		fallback: fallback,
+		cache:    make(map[string]cachedLocation),
+	}
+}
+
+// Location returns the user's timezone. A nil Timezones uses the server's.
+func (t *Timezones) Location(ctx context.Context, userID string) *time.Location {
+	if t == nil {
+		return time.Local
+	}
+	if userID == "" || t.client == nil {
+		return t.fallback
+	}
+
+	t.mu.Lock()
+	cached, ok := t.cache[userID]
+	t.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.loc
+	}
+
+	loc, ttl := t.lookup(ctx, userID)
+
+	t.mu.Lock()
+	t.cache[userID] = cachedLocation{loc: loc, expires: time.Now().Add(ttl)}
+	t.mu.Unlock()
+
+	return loc
+}
+
+// lookup asks Slack for the user's timezone, returning the fallback with a
+// short TTL when that fails
+func (t *Timezones) lookup(ctx context.Context, userID string) (*time.Location, time.Duration) {
+	name, err := t.client.UserTimezone(ctx, userID)
+	if err != nil {
+		slog.Warn("Failed to look up user timezone, using default", "error", err, "user_id", userID)
+		return t.fallback, timezoneRetry
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil || name == "" {
+		slog.Warn("Unknown user timezone, using default", "tz", name, "user_id", userID)
+		return t.fallback, timezoneTTL
+	}
+	return loc, timezoneTTL
+}
@@ -0,0 +1,102 @@
+package slack
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yossigruner/SlotBot/internal/calendar"
+	"github.com/yossigruner/SlotBot/internal/config"
+	"github.com/yossigruner/SlotBot/internal/domain"
+	"github.com/yossigruner/SlotBot/internal/waitlist"
+)
+
+// newTestTimezones serves users.info from zones, keyed by user ID, and
+// counts the lookups.
+func newTestTimezones(t *testing.T, zones map[string]string) (*Timezones, *atomic.Int32) {
+	t.Helper()
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.URL.Path != "/users.info" || r.FormValue("user") == "" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		tz, ok := zones[r.FormValue("user")]
+		if !ok {
+			w.Write([]byte(`{"ok": false, "error": "user_not_found"}`))
+			return
+		}
+		w.Write([]byte(`{"ok": true, "user": {"tz": "` + tz + `"}}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	client := NewAPIClient("xoxb-test")
+	client.baseURL = srv.URL + "/"
+	return NewTimezones(client, time.UTC), &calls
+}
+
+func TestTimezonesLocation(t *testing.T) {
+	tz, calls := newTestTimezones(t, map[string]string{"U123": "America/New_York"})
+	ctx := context.Background()
+
+	if got := tz.Location(ctx, "U123").String(); got != "America/New_York" {
+		t.Errorf("Location(U123) = %s, want America/New_York", got)
+	}
+	tz.Location(ctx, "U123")
+	if calls.Load() != 1 {
+		t.Errorf("users.info called %d times, want 1 with caching", calls.Load())
+	}
+
+	if got := tz.Location(ctx, "U404"); got != time.UTC {
+		t.Errorf("Location(unknown user) = %s, want the fallback", got)
+	}
+
+	var nilTimezones *Timezones
+	if got := nilTimezones.Location(ctx, "U123"); got != time.Local {
+		t.Errorf("nil Timezones Location() = %s, want time.Local", got)
+	}
+}
+
+func TestHandleBookInUserTimezone(t *testing.T) {
+	tz, _ := newTestTimezones(t, map[string]string{
+		"U123": "America/New_York",
+		"U999": "Asia/Jerusalem",
+	})
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("LoadLocation() error = %v", err)
+	}
+	rules := calendar.NewRules(nil, map[string]domain.Schedule{"staging": {Location: berlin}}, nil)
+
+	store := calendar.NewMemoryStore()
+	queue, _ := waitlist.Open("")
+	h := NewHandler(store, rules, waitlist.NewDispatcher(queue, store, rules, nil, nil, nil), tz, nil, nil, &config.Config{
+		MineHorizon: 14 * 24 * time.Hour,
+	})
+
+	got := slotCommand(t, h, "book staging api PROJ-1 tomorrow 10:00")
+	if !strings.Contains(got, "10:00 - 11:00") || !strings.Contains(got, "staging time (Europe/Berlin)") {
+		t.Errorf("book = %q, want New York times and the Berlin time", got)
+	}
+
+	newYork, _ := time.LoadLocation("America/New_York")
+	tomorrow := time.Now().In(newYork).AddDate(0, 0, 1)
+	want := time.Date(tomorrow.Year(), tomorrow.Month(), tomorrow.Day(), 10, 0, 0, 0, newYork)
+	events, err := store.ListEvents(context.Background(), want.Add(-time.Minute), want.Add(time.Hour))
+	if err != nil || len(events) != 1 || !events[0].StartTime.Equal(want) {
+		t.Fatalf("stored events = %v, %v, want one at %v", events, err, want)
+	}
+
+	// The same booking is shown in Jerusalem time to someone there
+	jerusalem, _ := time.LoadLocation("Asia/Jerusalem")
+	wantShown := want.In(jerusalem).Format("15:04")
+	if got := slotCommandAs(t, h, "U999", "bob", "list staging week"); !strings.Contains(got, wantShown) || !strings.Contains(got, "Asia/Jerusalem") {
+		t.Errorf("list = %q, want the booking at %s Jerusalem time", got, wantShown)
+	}
+}
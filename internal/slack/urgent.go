@@ -0,0 +1,343 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/yossigruner/SlotBot/internal/domain"
+	"github.com/yossigruner/SlotBot/internal/override"
+)
+
+// approvalTTL is how long an urgent request waits for a decision
+const approvalTTL = 2 * time.Hour
+
+// Action IDs of the buttons on approval messages
+const (
+	approveAction = "override_approve"
+	denyAction    = "override_deny"
+)
+
+// Approvals asks approvers, through interactive Slack messages, before an
+// urgent booking displaces others, and keeps the audit trail of decisions.
+type Approvals struct {
+	client  *APIClient
+	pending *override.Pending
+	audit   *override.Audit
+}
+
+func NewApprovals(client *APIClient, audit *override.Audit) *Approvals {
+	return &Approvals{
+		client:  client,
+		pending: override.NewPending(approvalTTL),
+		audit:   audit,
+	}
+}
+
+// requestOverride asks the env's approvers, or else the holders of the
+// conflicting bookings, to let the urgent bookings displace them. Nothing
+// changes until one of the approvers approves, or all of the holders do, as
+// each holder only speaks for their own bookings.
+func (h *Handler) requestOverride(w http.ResponseWriter, r *http.Request, bookings []domain.Booking, conflicts []domain.Event, reason string) {
+	if h.approvals == nil {
+		respond(w, "❌ Urgent bookings are not enabled")
+		return
+	}
+
+	requesterID := r.FormValue("user_id")
+	approvers := slices.Clone(h.rules.Approvers(bookings[0].Env))
+	holders := len(approvers) == 0
+	if holders {
+		for _, c := range conflicts {
+			if c.UserID != "" && !slices.Contains(approvers, c.UserID) {
+				approvers = append(approvers, c.UserID)
+			}
+		}
+	}
+	approvers = slices.DeleteFunc(approvers, func(id string) bool { return id == requesterID })
+	if len(approvers) == 0 {
+		respond(w, "❌ There is nobody else to approve this urgent booking, so nothing was changed")
+		return
+	}
+
+	req := h.approvals.pending.Add(override.Request{
+		Bookings:  bookings,
+		Reason:    reason,
+		Conflicts: conflicts,
+		Approvers: approvers,
+		Unanimous: holders,
+	})
+
+	var messages []override.Message
+	for _, approver := range approvers {
+		text := approvalText(req, h.timezones.Location(r.Context(), approver))
+		channel, ts, err := h.approvals.client.PostBlocks(r.Context(), approver, text, approvalBlocks(req.ID, text))
+		if err != nil {
+			slog.Error("Failed to send approval request", "error", err, "approver", approver, "request_id", req.ID)
+			continue
+		}
+		messages = append(messages, override.Message{UserID: approver, Channel: channel, TS: ts})
+	}
+	if len(messages) == 0 {
+		h.approvals.pending.Take(req.ID)
+		respond(w, "❌ Could not reach any approver, so nothing was changed")
+		return
+	}
+	h.approvals.pending.SetMessages(req.ID, messages)
+
+	slog.Info("Urgent booking requested", "request_id", req.ID, "env", bookings[0].Env, "user", bookings[0].User, "approvers", approvers)
+
+	who := "they approve"
+	if holders && len(approvers) > 1 {
+		who = "all of them approve"
+	}
+	respond(w, fmt.Sprintf("🚨 Asked %s to approve your urgent booking. It would displace:\n```\n%s```\nNothing changes unless %s; I'll DM you their answer.",
+		mentions(approvers), formatBookingsTable(conflicts, h.userLocation(r)), who))
+}
+
+// HandleInteraction receives button presses on approval messages. Slack
+// wants an answer within 3 seconds, so the decision is carried out in the
+// background.
+func (h *Handler) HandleInteraction(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Failed to parse form", http.StatusBadRequest)
+		return
+	}
+
+	var payload struct {
+		Type string `json:"type"`
+		User struct {
+			ID       string `json:"id"`
+			Username string `json:"username"`
+		} `json:"user"`
+		Actions []struct {
+			ActionID string `json:"action_id"`
+			Value    string `json:"value"`
+		} `json:"actions"`
+	}
+	if err := json.Unmarshal([]byte(r.FormValue("payload")), &payload); err != nil {
+		http.Error(w, "Invalid payload", http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+
+	if payload.Type != "block_actions" || h.approvals == nil {
+		return
+	}
+	for _, action := range payload.Actions {
+		if action.ActionID != approveAction && action.ActionID != denyAction {
+			continue
+		}
+		approve := action.ActionID == approveAction
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			h.decideOverride(ctx, action.Value, payload.User.ID, payload.User.Username, approve)
+		}()
+	}
+}
+
+// decideOverride carries out an approver's decision on a request and tells
+// everyone involved
+func (h *Handler) decideOverride(ctx context.Context, id, userID, userName string, approve bool) {
+	client := h.approvals.client
+
+	req, ok := h.approvals.pending.Get(id)
+	if !ok {
+		h.notify(ctx, userID, "This urgent booking request has expired or was already decided")
+		return
+	}
+	if !req.MayDecide(userID) {
+		h.notify(ctx, userID, "❌ You can't decide on this urgent booking request")
+		return
+	}
+	if approve {
+		var done bool
+		if req, done, ok = h.approvals.pending.Approve(id, userID); !ok {
+			return
+		}
+		if !done {
+			h.approvedSoFar(ctx, req, userID)
+			return
+		}
+	} else if req, ok = h.approvals.pending.Take(id); !ok {
+		return
+	}
+
+	b := req.Bookings[0]
+	what := fmt.Sprintf("%s / %s", b.Env, strings.Join(bookingServices(req.Bookings), ","))
+	rec := override.Record{
+		RequestID:   req.ID,
+		Reason:      req.Reason,
+		Requester:   b.User,
+		RequesterID: b.UserID,
+		DecidedBy:   userName,
+		DecidedByID: userID,
+		Bookings:    req.Bookings,
+	}
+	approvedBy := mentions(req.Approved)
+
+	var outcome, answer string
+	if !approve {
+		rec.Decision = override.Denied
+		outcome = fmt.Sprintf("❌ Denied by <@%s>", userID)
+		answer = fmt.Sprintf("❌ <@%s> denied your urgent booking of %s. Nothing was changed.", userID, what)
+	} else {
+		created, displaced, err := override.Apply(ctx, h.store, h.rules, req)
+		rec.ApprovedBy = req.Approved
+		rec.Displaced = displaced
+		h.notifyDisplaced(ctx, req, displaced)
+
+		switch {
+		case err != nil:
+			rec.Decision = override.Failed
+			rec.Error = err.Error()
+			slog.Error("Failed to apply urgent override", "error", err, "request_id", req.ID)
+			outcome = fmt.Sprintf("⚠️ Approved by %s, but it could not be carried out: %v", approvedBy, err)
+			answer = fmt.Sprintf("❌ %s approved your urgent booking of %s, but it could not be booked: %v\nNothing was changed.", approvedBy, what, err)
+			if errors.Is(err, override.ErrChanged) {
+				answer += " Try `--urgent` again."
+			}
+		default:
+			rec.Decision = override.Approved
+			outcome = "✅ Approved by " + approvedBy
+			loc := h.timezones.Location(ctx, b.UserID)
+			answer = fmt.Sprintf("✅ %s approved your urgent booking!\n```\n%s```%s", approvedBy, formatEventsTable(created, loc), h.homeTimes(created, loc))
+			slog.Info("Urgent override applied", "request_id", req.ID, "env", b.Env, "user", b.User, "approved_by", req.Approved, "displaced", len(displaced))
+		}
+	}
+
+	if err := h.approvals.audit.Record(rec); err != nil {
+		slog.Error("Failed to record override in audit trail", "error", err, "request_id", req.ID)
+	}
+
+	for _, m := range req.Messages {
+		text := approvalText(req, h.timezones.Location(ctx, m.UserID)) + "\n\n" + outcome
+		if err := client.UpdateMessage(ctx, m.Channel, m.TS, text); err != nil {
+			slog.Warn("Failed to update approval message", "error", err, "request_id", req.ID)
+		}
+	}
+	h.notify(ctx, b.UserID, answer)
+}
+
+// approvedSoFar tells the approver and the requester who else has to
+// approve before the request goes ahead
+func (h *Handler) approvedSoFar(ctx context.Context, req override.Request, userID string) {
+	waiting := mentions(req.Waiting())
+	for _, m := range req.Messages {
+		if m.UserID != userID {
+			continue
+		}
+		text := approvalText(req, h.timezones.Location(ctx, m.UserID)) + "\n\n✅ You approved; waiting for " + waiting
+		if err := h.approvals.client.UpdateMessage(ctx, m.Channel, m.TS, text); err != nil {
+			slog.Warn("Failed to update approval message", "error", err, "request_id", req.ID)
+		}
+	}
+	h.notify(ctx, req.Bookings[0].UserID, fmt.Sprintf("⏳ <@%s> approved your urgent booking; still waiting for %s", userID, waiting))
+}
+
+// notifyDisplaced tells the holders what happened to their bookings
+func (h *Handler) notifyDisplaced(ctx context.Context, req override.Request, displaced []override.Displacement) {
+	requester := req.Bookings[0].User
+	if id := req.Bookings[0].UserID; id != "" {
+		requester = "<@" + id + ">"
+	}
+
+	for _, d := range displaced {
+		e := d.Event
+		loc := h.timezones.Location(ctx, e.UserID)
+		booking := fmt.Sprintf("%s / %s on %s (%s)", e.Env, displayService(e.Service),
+			e.StartTime.In(loc).Format("Mon 02 Jan 15:04")+"-"+e.EndTime.In(loc).Format("15:04"), e.JiraTicket)
+
+		var change string
+		switch d.Action {
+		case override.ActionShortened:
+			change = "now ends at " + d.End.In(loc).Format("15:04")
+		case override.ActionMoved:
+			change = "moved to " + d.Start.In(loc).Format("Mon 02 Jan 15:04") + "-" + d.End.In(loc).Format("15:04")
+		default:
+			change = "cancelled, as there was no free slot to move it to"
+		}
+		h.notify(ctx, e.UserID, fmt.Sprintf("⚠️ Your booking of %s was %s to make room for an urgent booking by %s: _%s_",
+			booking, change, requester, req.Reason))
+	}
+}
+
+// notify sends a DM, logging failures
+func (h *Handler) notify(ctx context.Context, userID, text string) {
+	if userID == "" {
+		return
+	}
+	if err := h.approvals.client.SendDirectMessage(ctx, userID, text); err != nil {
+		slog.Warn("Failed to send direct message", "error", err, "user_id", userID)
+	}
+}
+
+// approvalText describes the request to an approver
+func approvalText(req override.Request, loc *time.Location) string {
+	b := req.Bookings[0]
+	requester := b.User
+	if b.UserID != "" {
+		requester = "<@" + b.UserID + ">"
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "🚨 *Urgent booking request* from %s\n*Reason:* %s\n*Wants:* %s / %s, %s-%s (%s)\n*Would displace:*",
+		requester, req.Reason, b.Env, strings.Join(bookingServices(req.Bookings), ","),
+		b.StartTime.In(loc).Format("Mon 02 Jan 15:04"), b.StartTime.Add(b.Duration).In(loc).Format("15:04"), strings.ToUpper(b.JiraTicket))
+	for _, c := range req.Conflicts {
+		holder := c.User
+		if c.UserID != "" {
+			holder = "<@" + c.UserID + ">"
+		}
+		fmt.Fprintf(&sb, "\n• %s: %s / %s, %s-%s (%s)", holder, c.Env, displayService(c.Service),
+			c.StartTime.In(loc).Format("Mon 02 Jan 15:04"), c.EndTime.In(loc).Format("15:04"), c.JiraTicket)
+	}
+	sb.WriteString("\nApproving cuts these bookings short or moves them to the next free slot.")
+	if req.Unanimous && len(req.Approvers) > 1 {
+		fmt.Fprintf(&sb, " It goes ahead only once %s all approve.", mentions(req.Approvers))
+	}
+	return sb.String()
+}
+
+// approvalBlocks is the approval message with Approve and Deny buttons
+func approvalBlocks(id, text string) []map[string]any {
+	button := func(label, style, actionID string) map[string]any {
+		return map[string]any{
+			"type":      "button",
+			"text":      map[string]any{"type": "plain_text", "text": label},
+			"style":     style,
+			"action_id": actionID,
+			"value":     id,
+		}
+	}
+	return []map[string]any{
+		{"type": "section", "text": map[string]any{"type": "mrkdwn", "text": text}},
+		{"type": "actions", "elements": []any{
+			button("Approve", "primary", approveAction),
+			button("Deny", "danger", denyAction),
+		}},
+	}
+}
+
+func bookingServices(bookings []domain.Booking) []string {
+	services := make([]string, len(bookings))
+	for i, b := range bookings {
+		services[i] = displayService(b.Service)
+	}
+	return services
+}
+
+func mentions(userIDs []string) string {
+	out := make([]string, len(userIDs))
+	for i, id := range userIDs {
+		out[i] = "<@" + id + ">"
+	}
+	return strings.Join(out, ", ")
+}
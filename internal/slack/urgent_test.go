@@ -0,0 +1,222 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yossigruner/SlotBot/internal/calendar"
+	"github.com/yossigruner/SlotBot/internal/config"
+	"github.com/yossigruner/SlotBot/internal/override"
+	"github.com/yossigruner/SlotBot/internal/waitlist"
+)
+
+// sentMessage is a chat.postMessage or chat.update call seen by the fake Slack API
+type sentMessage struct {
+	Method  string
+	Channel string           `json:"channel"`
+	Text    string           `json:"text"`
+	Blocks  []map[string]any `json:"blocks"`
+}
+
+// newUrgentHandler returns a handler whose Slack API calls are recorded
+func newUrgentHandler(t *testing.T, store calendar.BookingStore) (*Handler, func() []sentMessage, string) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var sent []sentMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		msg := sentMessage{Method: strings.TrimPrefix(r.URL.Path, "/")}
+		json.Unmarshal(body, &msg)
+
+		mu.Lock()
+		sent = append(sent, msg)
+		mu.Unlock()
+		w.Write([]byte(`{"ok": true, "channel": "D` + msg.Channel + `", "ts": "1.0"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	client := NewAPIClient("xoxb-test")
+	client.baseURL = srv.URL + "/"
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	rules := calendar.NewRules(nil, nil, nil)
+	queue, _ := waitlist.Open("")
+	h := NewHandler(store, rules, waitlist.NewDispatcher(queue, store, rules, nil, nil, nil), nil, nil,
+		NewApprovals(client, override.OpenAudit(auditPath)), &config.Config{MineHorizon: 14 * 24 * time.Hour})
+
+	return h, func() []sentMessage {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]sentMessage(nil), sent...)
+	}, auditPath
+}
+
+// requestID returns the request ID on the approval buttons sent to channel
+func requestID(t *testing.T, sent []sentMessage, channel string) string {
+	t.Helper()
+	for _, m := range sent {
+		if m.Method != "chat.postMessage" || m.Channel != channel || len(m.Blocks) < 2 {
+			continue
+		}
+		elements, _ := m.Blocks[1]["elements"].([]any)
+		if len(elements) > 0 {
+			if button, ok := elements[0].(map[string]any); ok {
+				return button["value"].(string)
+			}
+		}
+	}
+	t.Fatalf("no approval message sent to %s in %+v", channel, sent)
+	return ""
+}
+
+func TestHandleUrgentBook(t *testing.T) {
+	store := calendar.NewMemoryStore()
+	h, sent, auditPath := newUrgentHandler(t, store)
+	ctx := context.Background()
+
+	slotCommandAs(t, h, "U999", "bob", "book staging api PROJ-1 tomorrow 10:00 2h")
+
+	got := slotCommand(t, h, `book staging api OG-911 tomorrow 11:00 1h --urgent "verify prod hotfix"`)
+	if !strings.Contains(got, "Asked <@U999> to approve") {
+		t.Fatalf("urgent book = %q, want the holder asked to approve", got)
+	}
+	id := requestID(t, sent(), "U999")
+	if msg := sent()[0].Text; !strings.Contains(msg, "verify prod hotfix") || !strings.Contains(msg, "<@U123>") {
+		t.Errorf("approval message = %q, want the reason and requester", msg)
+	}
+
+	// Nothing changes before approval, and only approvers may decide
+	h.decideOverride(ctx, id, "U555", "eve", true)
+	if got := slotCommand(t, h, "mine"); strings.Contains(got, "OG-911") {
+		t.Errorf("mine before approval = %q, want no urgent booking", got)
+	}
+
+	h.decideOverride(ctx, id, "U999", "bob", true)
+
+	if got := slotCommand(t, h, "mine"); !strings.Contains(got, "OG-911") {
+		t.Errorf("mine after approval = %q, want the urgent booking", got)
+	}
+	if got := slotCommandAs(t, h, "U999", "bob", "mine"); !strings.Contains(got, "10:00 - 11:00") {
+		t.Errorf("bob's bookings = %q, want it cut short to 11:00", got)
+	}
+
+	var dmToBob, dmToAlice, updated bool
+	for _, m := range sent() {
+		switch {
+		case m.Method == "chat.postMessage" && m.Channel == "U999" && strings.Contains(m.Text, "now ends at 11:00"):
+			dmToBob = true
+		case m.Method == "chat.postMessage" && m.Channel == "U123" && strings.Contains(m.Text, "approved your urgent booking"):
+			dmToAlice = true
+		case m.Method == "chat.update" && m.Channel == "DU999" && strings.Contains(m.Text, "Approved by <@U999>"):
+			updated = true
+		}
+	}
+	if !dmToBob || !dmToAlice || !updated {
+		t.Errorf("messages = %+v, want DMs to bob and alice and the approval message updated", sent())
+	}
+
+	data, err := os.ReadFile(auditPath)
+	if err != nil || !strings.Contains(string(data), `"decision":"approved"`) || !strings.Contains(string(data), `"action":"shortened"`) {
+		t.Errorf("audit trail = %s, %v, want the approval and the shortened booking", data, err)
+	}
+
+	// A decided request can't be decided again
+	h.decideOverride(ctx, id, "U999", "bob", false)
+	if data, _ := os.ReadFile(auditPath); strings.Count(string(data), "\n") != 1 {
+		t.Errorf("audit trail = %s, want a single record", data)
+	}
+}
+
+func TestHandleUrgentBookDenied(t *testing.T) {
+	store := calendar.NewMemoryStore()
+	h, sent, auditPath := newUrgentHandler(t, store)
+
+	slotCommandAs(t, h, "U999", "bob", "book staging api PROJ-1 tomorrow 10:00 2h")
+	slotCommand(t, h, `book staging api OG-911 tomorrow 11:00 1h --urgent “hotfix”`)
+	h.decideOverride(context.Background(), requestID(t, sent(), "U999"), "U999", "bob", false)
+
+	if got := slotCommandAs(t, h, "U999", "bob", "mine"); !strings.Contains(got, "10:00 - 12:00") {
+		t.Errorf("bob's bookings = %q, want it unchanged", got)
+	}
+	if got := slotCommand(t, h, "mine"); strings.Contains(got, "OG-911") {
+		t.Errorf("mine = %q, want no urgent booking", got)
+	}
+	if data, _ := os.ReadFile(auditPath); !strings.Contains(string(data), `"decision":"denied"`) {
+		t.Errorf("audit trail = %s, want the denial", data)
+	}
+}
+
+func TestHandleUrgentBookNeedsEveryHolder(t *testing.T) {
+	store := calendar.NewMemoryStore()
+	h, sent, auditPath := newUrgentHandler(t, store)
+	ctx := context.Background()
+
+	slotCommandAs(t, h, "U999", "bob", "book staging api PROJ-1 tomorrow 10:00 2h")
+	slotCommandAs(t, h, "U888", "carol", "book staging web PROJ-2 tomorrow 11:00 1h")
+
+	got := slotCommand(t, h, `book staging api,web OG-911 tomorrow 11:00 1h --urgent "hotfix"`)
+	if !strings.Contains(got, "unless all of them approve") {
+		t.Fatalf("urgent book = %q, want both holders asked", got)
+	}
+	id := requestID(t, sent(), "U999")
+
+	// bob only speaks for their own booking
+	h.decideOverride(ctx, id, "U999", "bob", true)
+	if got := slotCommandAs(t, h, "U888", "carol", "mine"); !strings.Contains(got, "PROJ-2") {
+		t.Errorf("carol's bookings after bob approved = %q, want hers unchanged", got)
+	}
+	if got := slotCommand(t, h, "mine"); strings.Contains(got, "OG-911") {
+		t.Errorf("mine after bob approved = %q, want no urgent booking yet", got)
+	}
+	var waiting bool
+	for _, m := range sent() {
+		if m.Channel == "U123" && strings.Contains(m.Text, "still waiting for <@U888>") {
+			waiting = true
+		}
+	}
+	if !waiting {
+		t.Errorf("messages = %+v, want alice told carol has yet to approve", sent())
+	}
+
+	h.decideOverride(ctx, id, "U888", "carol", true)
+	if got := slotCommand(t, h, "mine"); !strings.Contains(got, "OG-911") {
+		t.Errorf("mine after both approved = %q, want the urgent booking", got)
+	}
+	if data, _ := os.ReadFile(auditPath); !strings.Contains(string(data), `"approved_by":["U999","U888"]`) {
+		t.Errorf("audit trail = %s, want both approvals", data)
+	}
+}
+
+func TestHandleUrgentBookValidation(t *testing.T) {
+	h := newTestHandler(calendar.NewMemoryStore())
+	slotCommandAs(t, h, "U999", "bob", "book staging api PROJ-1 tomorrow 10:00 2h")
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"Not enabled", `book staging api OG-1 tomorrow 11:00 --urgent "hotfix"`, "Urgent bookings are not enabled"},
+		{"Empty reason", `book staging api OG-1 tomorrow 11:00 --urgent ""`, "needs a reason"},
+		{"Recurring", `book staging api OG-1 tomorrow 11:00 --urgent "hotfix" --every daily --until 2099-01-01`, "can't be urgent"},
+		{"No conflict books straight away", `book staging web OG-1 tomorrow 11:00 --urgent "hotfix"`, "Booked!"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := slotCommand(t, h, tt.text); !strings.Contains(got, tt.want) {
+				t.Errorf("HandleUnified(%q) = %q, want it to contain %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package timeexpr
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// weekDays is how far "week" looks ahead, today included
+const weekDays = 7
+
+// ParseDays parses a day range for listings: "today", "tomorrow", "week",
+// a weekday name, an ISO date or "from..to" with any of those on either
+// side. The returned range starts at midnight and ends at midnight after
+// the last day, in now's location. Past days are allowed.
+func ParseDays(expr string, now time.Time) (Range, error) {
+	expr = strings.ToLower(strings.TrimSpace(expr))
+
+	if from, to, ok := strings.Cut(expr, ".."); ok {
+		start, err := parseListDay(from, now)
+		if err != nil {
+			return Range{}, err
+		}
+		last, err := parseListDay(to, now)
+		if err != nil {
+			return Range{}, err
+		}
+		if last.Before(start) {
+			return Range{}, fmt.Errorf("range ends on %s, before it starts", last.Format(time.DateOnly))
+		}
+		return Range{Start: start, End: last.AddDate(0, 0, 1)}, nil
+	}
+
+	switch expr {
+	case "", "today":
+		today := dayStart(now)
+		return Range{Start: today, End: today.AddDate(0, 0, 1)}, nil
+	case "week":
+		today := dayStart(now)
+		return Range{Start: today, End: today.AddDate(0, 0, weekDays)}, nil
+	}
+
+	day, err := parseListDay(expr, now)
+	if err != nil {
+		return Range{}, err
+	}
+	return Range{Start: day, End: day.AddDate(0, 0, 1)}, nil
+}
+
+// parseListDay resolves a single day for ParseDays. Unlike booking times,
+// "yesterday" is allowed here.
+func parseListDay(token string, now time.Time) (time.Time, error) {
+	if token == "yesterday" {
+		return dayStart(now).AddDate(0, 0, -1), nil
+	}
+	return parseDay(token, now)
+}
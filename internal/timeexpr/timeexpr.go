@@ -0,0 +1,176 @@
+// Package timeexpr parses the start-time expressions people type after
+// /slot book, such as "tomorrow 14:00", "mon 9:30", "in 45m",
+// "14:00-15:30", "until 17:00" and "eod", and the day ranges taken by
+// /slot list.
+package timeexpr
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// endOfDay is the time of day "eod" refers to
+const endOfDay = 18 * time.Hour
+
+// ErrInPast is returned for expressions that resolve to a time before now.
+var ErrInPast = errors.New("time is in the past")
+
+// Range is a parsed expression. End is zero unless the expression fixes it,
+// as ranges, "until" and "eod" do.
+type Range struct {
+	Start time.Time
+	End   time.Time
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tues": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+// Parse resolves expr against now. Wall-clock times are read in now's
+// location, so pass now in the user's timezone. Times that have already
+// passed are rejected with ErrInPast.
+func Parse(expr string, now time.Time) (Range, error) {
+	r, err := parse(strings.Fields(strings.ToLower(expr)), now)
+	if err != nil {
+		return Range{}, err
+	}
+
+	if r.Start.Before(now.Truncate(time.Minute)) {
+		return Range{}, fmt.Errorf("%s: %w", r.Start.Format("Mon 02 Jan 15:04"), ErrInPast)
+	}
+	if !r.End.IsZero() && !r.End.After(r.Start) {
+		return Range{}, fmt.Errorf("end %s is not after start %s", r.End.Format("15:04"), r.Start.Format("15:04"))
+	}
+
+	return r, nil
+}
+
+func parse(tokens []string, now time.Time) (Range, error) {
+	switch {
+	case len(tokens) == 0 || (len(tokens) == 1 && tokens[0] == "now"):
+		return Range{Start: now}, nil
+
+	case len(tokens) == 1 && tokens[0] == "eod":
+		return Range{Start: now, End: dayStart(now).Add(endOfDay)}, nil
+
+	case tokens[0] == "until":
+		end, err := parseDayTime(tokens[1:], now)
+		if err != nil {
+			return Range{}, fmt.Errorf("until: %w", err)
+		}
+		if !end.End.IsZero() {
+			return Range{}, errors.New("until takes a single time, not a range")
+		}
+		return Range{Start: now, End: end.Start}, nil
+
+	case tokens[0] == "in":
+		if len(tokens) != 2 {
+			return Range{}, errors.New("use 'in <duration>', e.g. in 45m")
+		}
+		d, err := time.ParseDuration(tokens[1])
+		if err != nil || d < 0 {
+			return Range{}, fmt.Errorf("invalid duration %q", tokens[1])
+		}
+		return Range{Start: now.Add(d)}, nil
+
+	case len(tokens) == 1:
+		// Absolute timestamps and bare relative durations ("2h" -> in 2 hours)
+		// Tokens are lower-cased, so put the T and Z back for RFC3339
+		if t, err := time.Parse(time.RFC3339, strings.ToUpper(tokens[0])); err == nil {
+			return Range{Start: t}, nil
+		}
+		if t, err := time.ParseInLocation("2006-01-02t15:04", tokens[0], now.Location()); err == nil {
+			return Range{Start: t}, nil
+		}
+		if d, err := time.ParseDuration(tokens[0]); err == nil && d >= 0 {
+			return Range{Start: now.Add(d)}, nil
+		}
+	}
+
+	return parseDayTime(tokens, now)
+}
+
+// parseDayTime parses "[day] HH:MM" or "[day] HH:MM-HH:MM", where day is
+// today, tomorrow, a weekday name or an ISO date. Without a day, today is meant.
+func parseDayTime(tokens []string, now time.Time) (Range, error) {
+	day := dayStart(now)
+	dayToken := ""
+	switch len(tokens) {
+	case 1:
+	case 2:
+		d, err := parseDay(tokens[0], now)
+		if err != nil {
+			return Range{}, err
+		}
+		day = d
+		dayToken = tokens[0]
+		tokens = tokens[1:]
+	default:
+		return Range{}, fmt.Errorf("unrecognised time %q", strings.Join(tokens, " "))
+	}
+
+	startStr, endStr, isRange := strings.Cut(tokens[0], "-")
+
+	start, err := parseClock(startStr, day)
+	if err != nil {
+		return Range{}, err
+	}
+
+	// A weekday that is today but whose time has passed means next week
+	if wd, ok := weekdays[dayToken]; ok && wd == now.Weekday() && start.Before(now) {
+		start = start.AddDate(0, 0, 7)
+		day = day.AddDate(0, 0, 7)
+	}
+
+	if !isRange {
+		return Range{Start: start}, nil
+	}
+
+	end, err := parseClock(endStr, day)
+	if err != nil {
+		return Range{}, err
+	}
+	return Range{Start: start, End: end}, nil
+}
+
+func parseDay(token string, now time.Time) (time.Time, error) {
+	today := dayStart(now)
+
+	switch token {
+	case "today":
+		return today, nil
+	case "tomorrow", "tmrw":
+		return today.AddDate(0, 0, 1), nil
+	}
+
+	if wd, ok := weekdays[token]; ok {
+		return today.AddDate(0, 0, (int(wd)-int(now.Weekday())+7)%7), nil
+	}
+
+	if d, err := time.ParseInLocation(time.DateOnly, token, now.Location()); err == nil {
+		return d, nil
+	}
+
+	return time.Time{}, fmt.Errorf("unrecognised day %q", token)
+}
+
+// parseClock reads "15:04" or "9:30" as a time on day
+func parseClock(s string, day time.Time) (time.Time, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unrecognised time %q, use HH:MM", s)
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, day.Location()), nil
+}
+
+func dayStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,133 @@
+package timeexpr
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Jerusalem")
+	if err != nil {
+		t.Fatalf("LoadLocation() error = %v", err)
+	}
+	// Wednesday
+	now := time.Date(2030, 1, 9, 10, 7, 0, 0, loc)
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2030, 1, day, hour, min, 0, 0, loc)
+	}
+
+	tests := []struct {
+		name      string
+		expr      string
+		wantStart time.Time
+		wantEnd   time.Time
+		wantErr   bool
+		wantPast  bool
+	}{
+		{name: "Empty means now", expr: "", wantStart: now},
+		{name: "Now", expr: "now", wantStart: now},
+		{name: "Clock today", expr: "14:00", wantStart: at(9, 14, 0)},
+		{name: "Single digit hour", expr: "tomorrow 7:05", wantStart: at(10, 7, 5)},
+		{name: "Tomorrow", expr: "tomorrow 14:00", wantStart: at(10, 14, 0)},
+		{name: "Tomorrow is case insensitive", expr: "Tomorrow 9:30", wantStart: at(10, 9, 30)},
+		{name: "Weekday", expr: "mon 9:30", wantStart: at(14, 9, 30)},
+		{name: "Full weekday name", expr: "friday 16:00", wantStart: at(11, 16, 0)},
+		{name: "Same weekday later today", expr: "wed 11:00", wantStart: at(9, 11, 0)},
+		{name: "Same weekday already passed", expr: "wed 9:00", wantStart: at(16, 9, 0)},
+		{name: "ISO date", expr: "2030-01-20 08:15", wantStart: at(20, 8, 15)},
+		{name: "Local timestamp", expr: "2030-01-09T15:00", wantStart: at(9, 15, 0)},
+		{name: "RFC3339", expr: "2030-01-09T13:00:00Z", wantStart: time.Date(2030, 1, 9, 13, 0, 0, 0, time.UTC)},
+		{name: "In minutes", expr: "in 45m", wantStart: now.Add(45 * time.Minute)},
+		{name: "In hours and minutes", expr: "in 1h30m", wantStart: now.Add(90 * time.Minute)},
+		{name: "Bare duration", expr: "2h", wantStart: now.Add(2 * time.Hour)},
+		{name: "Range", expr: "14:00-15:30", wantStart: at(9, 14, 0), wantEnd: at(9, 15, 30)},
+		{name: "Range tomorrow", expr: "tomorrow 9:00-10:00", wantStart: at(10, 9, 0), wantEnd: at(10, 10, 0)},
+		{name: "Until", expr: "until 17:00", wantStart: now, wantEnd: at(9, 17, 0)},
+		{name: "Until tomorrow", expr: "until tomorrow 9:00", wantStart: now, wantEnd: at(10, 9, 0)},
+		{name: "End of day", expr: "eod", wantStart: now, wantEnd: at(9, 18, 0)},
+		{name: "Past clock", expr: "8:00", wantPast: true},
+		{name: "Past timestamp", expr: "2029-12-31T10:00:00Z", wantPast: true},
+		{name: "Until a past time", expr: "until 9:00", wantErr: true},
+		{name: "Inverted range", expr: "15:00-14:00", wantErr: true},
+		{name: "Until a range", expr: "until 14:00-15:00", wantErr: true},
+		{name: "Unknown day", expr: "someday 14:00", wantErr: true},
+		{name: "Bad clock", expr: "25:00", wantErr: true},
+		{name: "In without duration", expr: "in", wantErr: true},
+		{name: "Negative duration", expr: "in -1h", wantErr: true},
+		{name: "Trailing garbage", expr: "tomorrow 14:00 please", wantErr: true},
+		{name: "Words", expr: "later", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.expr, now)
+			if tt.wantPast {
+				if !errors.Is(err, ErrInPast) {
+					t.Fatalf("Parse(%q) error = %v, want ErrInPast", tt.expr, err)
+				}
+				return
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) error = %v, wantErr %v", tt.expr, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !got.Start.Equal(tt.wantStart) {
+				t.Errorf("Parse(%q) start = %v, want %v", tt.expr, got.Start, tt.wantStart)
+			}
+			if !got.End.Equal(tt.wantEnd) {
+				t.Errorf("Parse(%q) end = %v, want %v", tt.expr, got.End, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestParseDays(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Jerusalem")
+	if err != nil {
+		t.Fatalf("LoadLocation() error = %v", err)
+	}
+	// Wednesday
+	now := time.Date(2030, 1, 9, 10, 7, 0, 0, loc)
+	day := func(d int) time.Time {
+		return time.Date(2030, 1, d, 0, 0, 0, 0, loc)
+	}
+
+	tests := []struct {
+		name      string
+		expr      string
+		wantStart time.Time
+		wantEnd   time.Time
+		wantErr   bool
+	}{
+		{name: "Empty means today", expr: "", wantStart: day(9), wantEnd: day(10)},
+		{name: "Today", expr: "today", wantStart: day(9), wantEnd: day(10)},
+		{name: "Tomorrow", expr: "Tomorrow", wantStart: day(10), wantEnd: day(11)},
+		{name: "Yesterday", expr: "yesterday", wantStart: day(8), wantEnd: day(9)},
+		{name: "Week", expr: "week", wantStart: day(9), wantEnd: day(16)},
+		{name: "Weekday", expr: "mon", wantStart: day(14), wantEnd: day(15)},
+		{name: "ISO date", expr: "2030-01-20", wantStart: day(20), wantEnd: day(21)},
+		{name: "Date range", expr: "2030-01-20..2030-01-22", wantStart: day(20), wantEnd: day(23)},
+		{name: "Mixed range", expr: "today..fri", wantStart: day(9), wantEnd: day(12)},
+		{name: "Backwards range", expr: "fri..today", wantErr: true},
+		{name: "Open range", expr: "today..", wantErr: true},
+		{name: "Unknown day", expr: "someday", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDays(tt.expr, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDays(%q) error = %v, wantErr %v", tt.expr, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !got.Start.Equal(tt.wantStart) || !got.End.Equal(tt.wantEnd) {
+				t.Errorf("ParseDays(%q) = %v..%v, want %v..%v", tt.expr, got.Start, got.End, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package waitlist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yossigruner/SlotBot/internal/calendar"
+	"github.com/yossigruner/SlotBot/internal/domain"
+	"github.com/yossigruner/SlotBot/internal/grid"
+)
+
+// Notifier sends a direct message to a Slack user.
+type Notifier interface {
+	SendDirectMessage(ctx context.Context, userID, text string) error
+}
+
+// Locator returns a user's timezone, so times in messages read right for them.
+type Locator interface {
+	Location(ctx context.Context, userID string) *time.Location
+}
+
+// Dispatcher books environments for the people at the head of the waitlist
+// as soon as they are free.
+type Dispatcher struct {
+	queue    *Queue
+	store    calendar.BookingStore
+	rules    *calendar.Rules
+	notifier Notifier
+	locator  Locator
+	teams    calendar.TeamSource
+
+	// mu serialises processing so two triggers can't book the same gap twice
+	mu sync.Mutex
+}
+
+// NewDispatcher creates a dispatcher that books under rules. notifier,
+// locator and teams may be nil, to skip DMs, to show times in the server's
+// timezone or to skip team quotas.
+func NewDispatcher(queue *Queue, store calendar.BookingStore, rules *calendar.Rules, notifier Notifier, locator Locator, teams calendar.TeamSource) *Dispatcher {
+	return &Dispatcher{
+		queue:    queue,
+		store:    store,
+		rules:    rules,
+		notifier: notifier,
+		locator:  locator,
+		teams:    teams,
+	}
+}
+
+// Queue returns the waitlist the dispatcher serves.
+func (d *Dispatcher) Queue() *Queue {
+	return d.queue
+}
+
+// Run processes every queue each interval until ctx is cancelled, so
+// bookings that simply run out hand over to the next in line.
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.Process(ctx, "")
+		}
+	}
+}
+
+// Process books every queue head in env (or all envs if env is empty) whose
+// env/service is free right now, and tells them by DM. It returns the
+// bookings it made.
+func (d *Dispatcher) Process(ctx context.Context, env string) []domain.Event {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var booked []domain.Event
+	for _, entry := range d.queue.Entries() {
+		if env != "" && !strings.EqualFold(entry.Env, env) {
+			continue
+		}
+		// Only the head of each env/service queue may be served
+		if d.queue.Position(entry.ID) != 1 {
+			continue
+		}
+
+		event, err := d.tryBook(ctx, entry)
+		if err != nil {
+			slog.Error("Failed to book for waitlist entry", "error", err, "entry_id", entry.ID, "env", entry.Env, "service", entry.Service)
+			continue
+		}
+		if event != nil {
+			booked = append(booked, *event)
+		}
+	}
+	return booked
+}
+
+// tryBook books the entry if its env/service is free now and the policy
+// and quotas allow it. It returns nil without error when it has to wait.
+func (d *Dispatcher) tryBook(ctx context.Context, entry Entry) (*domain.Event, error) {
+	// Start on the grid like a booking for now, then on the env's step
+	now := grid.Round(time.Now())
+	if g := d.rules.Granularity(entry.Env); g > 0 {
+		now = now.Add(-sinceMidnight(now) % g)
+	}
+	booking := domain.Booking{
+		Env:        entry.Env,
+		Service:    entry.Service,
+		JiraTicket: entry.JiraTicket,
+		StartTime:  now,
+		Duration:   entry.Duration,
+		User:       entry.User,
+		UserID:     entry.UserID,
+	}
+
+	// An entry the policy refuses right now, e.g. outside working hours,
+	// waits until it allows it
+	if err := d.rules.ValidateBooking(booking); err != nil {
+		slog.Info("Waitlist entry stays queued", "reason", err, "entry_id", entry.ID, "env", entry.Env, "service", entry.Service)
+		return nil, nil
+	}
+
+	events, err := d.store.ListEvents(ctx, now, now.Add(entry.Duration))
+	if err != nil {
+		return nil, fmt.Errorf("list events: %w", err)
+	}
+	if d.rules.CheckConflict(booking, events) != nil {
+		return nil, nil
+	}
+
+	// An entry over its quota waits until it has quota again
+	if err := d.rules.CheckQuota(ctx, d.store, []domain.Booking{booking}, d.teams); err != nil {
+		var quotaErr *calendar.PolicyError
+		if errors.As(err, &quotaErr) {
+			slog.Info("Waitlist entry stays queued", "reason", err, "entry_id", entry.ID, "env", entry.Env, "service", entry.Service)
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	event, err := d.store.CreateEvent(ctx, booking)
+	if err != nil {
+		return nil, fmt.Errorf("create event: %w", err)
+	}
+
+	if _, err := d.queue.Remove(entry.ID); err != nil {
+		slog.Error("Failed to remove booked waitlist entry", "error", err, "entry_id", entry.ID)
+	}
+
+	slog.Info("Booked from waitlist", "env", event.Env, "service", event.Service, "user", entry.User, "event_id", event.ID)
+
+	loc := time.Local
+	if d.locator != nil {
+		loc = d.locator.Location(ctx, entry.UserID)
+	}
+
+	message := fmt.Sprintf("🎉 Your turn! %s / %s is free and booked for you from %s to %s (%s).\nBooking ID: `%s` - use `/slot done %s` if you finish early or `/slot cancel %s` if you no longer need it.",
+		event.Env, event.Service,
+		event.StartTime.In(loc).Format("15:04"), event.EndTime.In(loc).Format("15:04"),
+		event.JiraTicket, event.ID, event.ID, event.ID)
+	if d.notifier != nil && entry.UserID != "" {
+		if err := d.notifier.SendDirectMessage(ctx, entry.UserID, message); err != nil {
+			slog.Error("Failed to notify waitlisted user", "error", err, "user_id", entry.UserID)
+		}
+	}
+
+	return event, nil
+}
+
+func sinceMidnight(t time.Time) time.Duration {
+	return t.Sub(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location()))
+}
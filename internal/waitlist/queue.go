@@ -0,0 +1,177 @@
+// Package waitlist keeps a FIFO queue of people waiting for a busy
+// environment and books it for the next in line once it frees up.
+package waitlist
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry is a request waiting for an env/service to become free.
+type Entry struct {
+	ID         string        `json:"id"`
+	Env        string        `json:"env"`
+	Service    string        `json:"service"`
+	JiraTicket string        `json:"jira_ticket"`
+	Duration   time.Duration `json:"duration"`
+	User       string        `json:"user"`
+	UserID     string        `json:"user_id"`
+	QueuedAt   time.Time     `json:"queued_at"`
+}
+
+// Queue holds the waitlist entries of every env/service in arrival order.
+// It is saved to a JSON file on every change so it survives restarts.
+type Queue struct {
+	mu      sync.Mutex
+	path    string
+	entries []Entry
+}
+
+// Open loads the queue from path, starting empty if the file doesn't exist.
+// An empty path keeps the queue in memory only.
+func Open(path string) (*Queue, error) {
+	q := &Queue{path: path}
+	if path == "" {
+		return q, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return q, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read waitlist: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &q.entries); err != nil {
+		return nil, fmt.Errorf("unable to parse waitlist %s: %w", path, err)
+	}
+	return q, nil
+}
+
+// Add appends an entry to the end of its env/service queue and returns the
+// stored entry with its 1-based position in that queue.
+func (q *Queue) Add(e Entry) (Entry, int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, existing := range q.entries {
+		if existing.UserID == e.UserID && sameQueue(existing, e) {
+			return Entry{}, 0, fmt.Errorf("you are already waiting for %s / %s", e.Env, e.Service)
+		}
+	}
+
+	e.ID = newEntryID()
+	e.Env = strings.ToLower(e.Env)
+	e.Service = strings.ToLower(e.Service)
+	if e.QueuedAt.IsZero() {
+		e.QueuedAt = time.Now()
+	}
+
+	q.entries = append(q.entries, e)
+	if err := q.save(); err != nil {
+		q.entries = q.entries[:len(q.entries)-1]
+		return Entry{}, 0, err
+	}
+
+	return e, q.position(e), nil
+}
+
+// Remove deletes an entry, reporting whether it was queued.
+func (q *Queue) Remove(id string) (bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, e := range q.entries {
+		if e.ID != id {
+			continue
+		}
+		q.entries = append(q.entries[:i:i], q.entries[i+1:]...)
+		return true, q.save()
+	}
+	return false, nil
+}
+
+// Entries returns every queued entry in arrival order.
+func (q *Queue) Entries() []Entry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return append([]Entry(nil), q.entries...)
+}
+
+// Position returns the 1-based position of the entry in its env/service
+// queue, or 0 if it isn't queued.
+func (q *Queue) Position(id string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, e := range q.entries {
+		if e.ID == id {
+			return q.position(e)
+		}
+	}
+	return 0
+}
+
+func (q *Queue) position(e Entry) int {
+	pos := 0
+	for _, other := range q.entries {
+		if sameQueue(other, e) {
+			pos++
+		}
+		if other.ID == e.ID {
+			return pos
+		}
+	}
+	return 0
+}
+
+// save writes the queue to a temporary file and renames it into place, so a
+// crash never leaves a half-written waitlist behind.
+func (q *Queue) save() error {
+	if q.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(q.entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to encode waitlist: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(q.path), ".waitlist-*")
+	if err != nil {
+		return fmt.Errorf("unable to save waitlist: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("unable to save waitlist: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("unable to save waitlist: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), q.path); err != nil {
+		return fmt.Errorf("unable to save waitlist: %w", err)
+	}
+	return nil
+}
+
+func sameQueue(a, b Entry) bool {
+	return strings.EqualFold(a.Env, b.Env) && strings.EqualFold(a.Service, b.Service)
+}
+
+func newEntryID() string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
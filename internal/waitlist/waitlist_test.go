@@ -0,0 +1,140 @@
+package waitlist
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yossigruner/SlotBot/internal/calendar"
+	"github.com/yossigruner/SlotBot/internal/domain"
+)
+
+type recordingNotifier struct {
+	mu   sync.Mutex
+	sent map[string]string
+}
+
+func (n *recordingNotifier) SendDirectMessage(ctx context.Context, userID, text string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.sent[userID] = text
+	return nil
+}
+
+func TestQueuePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "waitlist.json")
+
+	q, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	first, pos, err := q.Add(Entry{Env: "staging", Service: "api", UserID: "U1", Duration: time.Hour})
+	if err != nil || pos != 1 {
+		t.Fatalf("Add() = %d, %v, want position 1", pos, err)
+	}
+	if _, pos, _ := q.Add(Entry{Env: "staging", Service: "api", UserID: "U2", Duration: time.Hour}); pos != 2 {
+		t.Errorf("Add() second entry position = %d, want 2", pos)
+	}
+	if _, pos, _ := q.Add(Entry{Env: "qa", Service: "api", UserID: "U2", Duration: time.Hour}); pos != 1 {
+		t.Errorf("Add() other queue position = %d, want 1", pos)
+	}
+	if _, _, err := q.Add(Entry{Env: "staging", Service: "api", UserID: "U1"}); err == nil {
+		t.Errorf("Add() duplicate entry error = nil, want error")
+	}
+
+	// Reopen to check the queue survives a restart
+	q, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open() reopen error = %v", err)
+	}
+	if got := len(q.Entries()); got != 3 {
+		t.Fatalf("Entries() after reopen = %d, want 3", got)
+	}
+
+	if removed, err := q.Remove(first.ID); !removed || err != nil {
+		t.Fatalf("Remove() = %v, %v, want removed", removed, err)
+	}
+	if pos := q.Position(q.Entries()[0].ID); pos != 1 {
+		t.Errorf("Position() of next in line = %d, want 1", pos)
+	}
+}
+
+func TestDispatcherProcess(t *testing.T) {
+	ctx := context.Background()
+	store := calendar.NewMemoryStore()
+	notifier := &recordingNotifier{sent: make(map[string]string)}
+	q, _ := Open("")
+	d := NewDispatcher(q, store, calendar.NewRules(nil, nil, nil), notifier, nil, nil)
+
+	now := time.Now()
+	busy, _ := store.CreateEvent(ctx, domain.Booking{
+		Env:       "staging",
+		Service:   "api",
+		StartTime: now.Add(-time.Hour),
+		Duration:  2 * time.Hour,
+		UserID:    "U0",
+	})
+
+	q.Add(Entry{Env: "staging", Service: "api", JiraTicket: "PROJ-1", UserID: "U1", Duration: 30 * time.Minute})
+	q.Add(Entry{Env: "staging", Service: "api", JiraTicket: "PROJ-2", UserID: "U2", Duration: 30 * time.Minute})
+
+	if booked := d.Process(ctx, "staging"); len(booked) != 0 {
+		t.Fatalf("Process() while busy booked %d, want 0", len(booked))
+	}
+
+	// The holder releases the environment
+	store.DeleteEvent(ctx, busy.ID)
+
+	booked := d.Process(ctx, "staging")
+	if len(booked) != 1 || booked[0].UserID != "U1" {
+		t.Fatalf("Process() after release = %+v, want a booking for U1 only", booked)
+	}
+	if _, ok := notifier.sent["U1"]; !ok {
+		t.Errorf("Process() did not notify U1")
+	}
+	if entries := q.Entries(); len(entries) != 1 || entries[0].UserID != "U2" {
+		t.Errorf("Entries() after booking = %+v, want U2 still waiting", entries)
+	}
+}
+
+func TestDispatcherWaitsForQuota(t *testing.T) {
+	policy, err := calendar.ParsePolicy([]byte("envs:\n  staging:\n    quota:\n      concurrent: 1"))
+	if err != nil {
+		t.Fatalf("ParsePolicy() error = %v", err)
+	}
+
+	ctx := context.Background()
+	store := calendar.NewMemoryStore()
+	q, _ := Open("")
+	d := NewDispatcher(q, store, calendar.NewRules(policy, nil, nil), nil, nil, nil)
+
+	// U1 already holds another service, so has no quota left
+	held, _ := store.CreateEvent(ctx, domain.Booking{
+		Env:       "staging",
+		Service:   "web",
+		StartTime: time.Now().Add(-time.Hour),
+		Duration:  2 * time.Hour,
+		UserID:    "U1",
+	})
+	q.Add(Entry{Env: "staging", Service: "api", JiraTicket: "PROJ-1", UserID: "U1", Duration: 30 * time.Minute})
+
+	if booked := d.Process(ctx, "staging"); len(booked) != 0 {
+		t.Fatalf("Process() over quota booked %+v, want nothing", booked)
+	}
+	if entries := q.Entries(); len(entries) != 1 {
+		t.Fatalf("Entries() over quota = %+v, want the entry still queued", entries)
+	}
+
+	store.DeleteEvent(ctx, held.ID)
+
+	booked := d.Process(ctx, "staging")
+	if len(booked) != 1 {
+		t.Fatalf("Process() with quota booked %+v, want one booking", booked)
+	}
+	if start := booked[0].StartTime; start.Minute()%15 != 0 || start.Second() != 0 {
+		t.Errorf("StartTime = %v, want it on the 15-minute grid", start)
+	}
+}